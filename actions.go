@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/alexeldeib/incli/client"
+	kitlog "github.com/go-kit/log"
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+)
+
+var actionStatuses = []client.ActionV2Status{
+	client.ActionV2StatusOutstanding,
+	client.ActionV2StatusCompleted,
+	client.ActionV2StatusNotDoing,
+	client.ActionV2StatusDeleted,
+}
+
+func NewActionsCommand() *cobra.Command {
+	root := &cobra.Command{
+		Use:     "actions",
+		Aliases: []string{"action"},
+		Short:   "inspect the actions taken during incidents",
+	}
+
+	root.AddCommand(NewGetActionsCommand())
+
+	return root
+}
+
+func NewGetActionsCommand() *cobra.Command {
+	opts := &GetActionsOptions{}
+	cmd := &cobra.Command{
+		Use:   "get",
+		Short: "get the actions of one incident, or across every incident",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, logger, cl, err := setup()
+			if err != nil {
+				fmt.Printf("failed to setup: %s", err)
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger, cl); err != nil {
+				logger.Log("msg", "failed to run", "error", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.incident, "incident", "", "only actions of this incident, e.g. INC-123 or an incident id")
+	cmd.Flags().StringVar(&opts.status, "status", "", "only actions with this status, one of: outstanding, completed, not_doing, deleted")
+	cmd.Flags().StringVar(&opts.mode, "mode", "", "only actions of incidents in this mode, one of: standard, retrospective, test, tutorial. Defaults to standard and retrospective")
+	cmd.Flags().StringVar(&opts.assignee, "assignee", "", "only actions assigned to this user, by email, Slack user ID, name or ID")
+	cmd.Flags().StringVarP(&opts.output, "output", "o", outputJSON, "output format, one of: json, jsonl, table")
+
+	return cmd
+}
+
+type GetActionsOptions struct {
+	incident string
+	status   string
+	mode     string
+	assignee string
+	output   string
+}
+
+func (o *GetActionsOptions) Run(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) error {
+	if err := validateOutput(o.output, outputJSON, outputJSONL, outputTable); err != nil {
+		return err
+	}
+
+	if o.status != "" && !lo.Contains(actionStatuses, client.ActionV2Status(o.status)) {
+		return fmt.Errorf("--status must be one of %v: %q", actionStatuses, o.status)
+	}
+
+	if o.mode != "" && !lo.Contains(incidentModes, o.mode) {
+		return fmt.Errorf("--mode must be one of %v: %q", incidentModes, o.mode)
+	}
+
+	params := client.ActionsV2ListParams{}
+	if o.incident != "" {
+		id, err := FindIncidentID(ctx, logger, cl, o.incident)
+		if err != nil {
+			return fmt.Errorf("failed to find incident: %s", err)
+		}
+		params.IncidentId = &id
+	}
+	if o.mode != "" {
+		params.IncidentMode = lo.ToPtr(client.ActionsV2ListParamsIncidentMode(o.mode))
+	}
+
+	var assigneeID string
+	if o.assignee != "" {
+		user, err := FindUser(ctx, logger, cl, o.assignee)
+		if err != nil {
+			return fmt.Errorf("failed to find assignee: %s", err)
+		}
+		assigneeID = user.Id
+	}
+
+	res, err := ListAllActions(ctx, logger, cl, params)
+	if err != nil {
+		return fmt.Errorf("failed to list actions: %s", err)
+	}
+
+	actions := lo.Filter(res, func(action client.ActionV2, _ int) bool {
+		if o.status != "" && string(action.Status) != o.status {
+			return false
+		}
+		return assigneeID == "" || (action.Assignee != nil && action.Assignee.Id == assigneeID)
+	})
+
+	if o.output == outputTable {
+		references, err := IncidentReferencesByID(ctx, logger, cl, lo.Map(actions, func(action client.ActionV2, _ int) string { return action.IncidentId }))
+		if err != nil {
+			return fmt.Errorf("failed to find incident references: %s", err)
+		}
+
+		rows := lo.Map(actions, func(action client.ActionV2, _ int) []string {
+			return []string{action.Id, references[action.IncidentId], string(action.Status), userLabel(action.Assignee), action.Description}
+		})
+		return writeTable([]string{"id", "incident", "status", "assignee", "description"}, rows)
+	}
+
+	w, err := newRecordWriter(o.output)
+	if err != nil {
+		return err
+	}
+
+	for _, action := range actions {
+		if err := w.Write(action); err != nil {
+			return err
+		}
+	}
+
+	return w.Close()
+}
@@ -3,27 +3,159 @@ package main
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
 
 	"github.com/alexeldeib/incli/client"
 	kitlog "github.com/go-kit/log"
+	"github.com/go-kit/log/level"
 	"github.com/pkg/errors"
 	"github.com/samber/lo"
-	"github.com/sanity-io/litter"
 )
 
+// defaultPageSize is the largest page size accepted by the paginated list endpoints.
+const defaultPageSize = 250
+
+// errStopWalk can be returned from a Walk* callback to end pagination early. The walk
+// itself then returns nil.
+var errStopWalk = errors.New("stop walk")
+
+// stopWalk translates the callback error of a walk into the walk's own return value.
+func stopWalk(err error) error {
+	if errors.Is(err, errStopWalk) {
+		return nil
+	}
+	return err
+}
+
+// FindCustomField finds a custom field by ID, or otherwise by name ignoring case.
+func FindCustomField(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, target string) (*client.CustomFieldV2, error) {
+	if IsULID(target) {
+		return FindCustomFieldByID(ctx, logger, cl, target)
+	}
+	return FindCustomFieldByName(ctx, logger, cl, target)
+}
+
+func FindCustomFieldByID(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, targetID string) (*client.CustomFieldV2, error) {
+	res, err := cl.CustomFieldsV2ShowWithResponse(ctx, targetID)
+	if err != nil {
+		return nil, errors.Wrap(err, "showing custom field")
+	}
+	return &res.JSON200.CustomField, nil
+}
+
 func FindCustomFieldByName(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, targetName string) (*client.CustomFieldV2, error) {
 	customFields, err := ListAllCustomFields(ctx, logger, cl)
 	if err != nil {
 		return nil, errors.Wrap(err, "listing custom fields types")
 	}
 
+	m := newMatcher("custom field", targetName, func(v client.CustomFieldV2) string {
+		return fmt.Sprintf("%q (%s)", v.Name, v.Id)
+	})
 	for _, v := range customFields {
-		if v.Name == targetName {
-			return &v, nil
+		m.add(v, v.Name)
+	}
+
+	return m.one()
+}
+
+func CreateCustomField(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, body client.CustomFieldsV2CreateJSONRequestBody) (*client.CustomFieldV2, error) {
+	defer responseCache.invalidate(cacheKeyCustomFields)
+
+	res, err := cl.CustomFieldsV2CreateWithResponse(ctx, body)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating custom field")
+	}
+	return &res.JSON201.CustomField, nil
+}
+
+func UpdateCustomField(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, id string, body client.CustomFieldsV2UpdateJSONRequestBody) (*client.CustomFieldV2, error) {
+	defer responseCache.invalidate(cacheKeyCustomFields)
+
+	res, err := cl.CustomFieldsV2UpdateWithResponse(ctx, id, body)
+	if err != nil {
+		return nil, errors.Wrap(err, "updating custom field")
+	}
+	return &res.JSON200.CustomField, nil
+}
+
+// DeleteCustomField deletes a custom field along with its options and every value
+// set on incidents.
+func DeleteCustomField(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, id string) error {
+	defer responseCache.invalidate(cacheKeyCustomFields)
+
+	if _, err := cl.CustomFieldsV2DeleteWithResponse(ctx, id); err != nil {
+		return errors.Wrap(err, "deleting custom field")
+	}
+	return nil
+}
+
+// ListAllCustomFieldOptions returns the options of a select custom field, in the order
+// the API sorts them.
+func ListAllCustomFieldOptions(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, customFieldID string) ([]client.CustomFieldOptionV1, error) {
+	results := []client.CustomFieldOptionV1{}
+	params := &client.CustomFieldOptionsV1ListParams{
+		CustomFieldId: customFieldID,
+		PageSize:      lo.ToPtr(int64(defaultPageSize)),
+	}
+
+	for {
+		res, err := cl.CustomFieldOptionsV1ListWithResponse(ctx, params)
+		if err != nil {
+			return nil, errors.Wrap(err, "listing custom field options")
+		}
+
+		results = append(results, res.JSON200.CustomFieldOptions...)
+
+		after := res.JSON200.PaginationMeta.After
+		if after == nil || *after == "" || len(res.JSON200.CustomFieldOptions) == 0 {
+			return results, nil
 		}
+		params.After = after
+	}
+}
+
+// FindCustomFieldOption finds an option of a custom field by ID, or otherwise by value
+// ignoring case.
+func FindCustomFieldOption(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, customFieldID, target string) (*client.CustomFieldOptionV1, error) {
+	options, err := ListAllCustomFieldOptions(ctx, logger, cl, customFieldID)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil, errors.Errorf("catalog type %q not found", targetName)
+	m := newMatcher("custom field option", target, func(v client.CustomFieldOptionV1) string {
+		return fmt.Sprintf("%q (%s)", v.Value, v.Id)
+	})
+	for _, v := range options {
+		m.addWithID(v, v.Id, v.Value)
+	}
+
+	return m.one()
+}
+
+func CreateCustomFieldOption(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, body client.CustomFieldOptionsV1CreateJSONRequestBody) (*client.CustomFieldOptionV1, error) {
+	res, err := cl.CustomFieldOptionsV1CreateWithResponse(ctx, body)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating custom field option")
+	}
+	return &res.JSON201.CustomFieldOption, nil
+}
+
+func UpdateCustomFieldOption(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, id string, body client.CustomFieldOptionsV1UpdateJSONRequestBody) (*client.CustomFieldOptionV1, error) {
+	res, err := cl.CustomFieldOptionsV1UpdateWithResponse(ctx, id, body)
+	if err != nil {
+		return nil, errors.Wrap(err, "updating custom field option")
+	}
+	return &res.JSON200.CustomFieldOption, nil
+}
+
+func DeleteCustomFieldOption(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, id string) error {
+	if _, err := cl.CustomFieldOptionsV1DeleteWithResponse(ctx, id); err != nil {
+		return errors.Wrap(err, "deleting custom field option")
+	}
+	return nil
 }
 
 func FindCatalogTypeByID(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, targetID string) (*client.CatalogTypeV2, error) {
@@ -41,19 +173,20 @@ func FindCatalogTypeByID(ctx context.Context, logger kitlog.Logger, cl *client.C
 	return nil, errors.Errorf("catalog type %q not found", targetID)
 }
 
+// FindCatalogTypeByName finds a catalog type by its name or type name, e.g. Service or
+// Custom["Service"], ignoring case.
 func FindCatalogTypeByName(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, targetName string) (*client.CatalogTypeV2, error) {
 	catalogTypes, err := ListAllCatalogTypes(ctx, logger, cl)
 	if err != nil {
 		return nil, fmt.Errorf("finding catalog type by name: %s", err)
 	}
 
+	m := newMatcher("catalog type", targetName, describeCatalogType)
 	for _, v := range catalogTypes {
-		if v.Name == targetName {
-			return &v, nil
-		}
+		m.add(v, v.Name, v.TypeName)
 	}
 
-	return nil, errors.Errorf("catalog type %q not found", targetName)
+	return m.one()
 }
 
 func FindCatalogEntryByNameWithTypeName(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, targetName string, typeName string) (*client.CatalogEntryV2, error) {
@@ -70,39 +203,53 @@ func FindCatalogEntryByNameWithTypeName(ctx context.Context, logger kitlog.Logge
 	return catalogEntry, nil
 }
 
+// FindCatalogEntryByNameWithTypeID finds the entry of a catalog type whose name, alias
+// or external ID is targetName, ignoring case.
 func FindCatalogEntryByNameWithTypeID(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, targetName string, typeID string) (*client.CatalogEntryV2, error) {
-	var (
-		after    *string
-		pageSize = 250
-	)
+	m := newMatcher("catalog entry", targetName, describeCatalogEntry)
 
-	for {
-		page, err := cl.CatalogV2ListEntriesWithResponse(ctx, &client.CatalogV2ListEntriesParams{
-			CatalogTypeId: typeID,
-			PageSize:      &pageSize,
-			After:         after,
-		})
-		if err != nil {
-			return nil, fmt.Errorf("listing catalog entries: %s", err)
-		}
+	err := WalkCatalogEntriesByTypeID(ctx, logger, cl, typeID, defaultPageSize, func(candidate client.CatalogEntryV2) error {
+		m.add(candidate, catalogEntryNames(candidate)...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing catalog entries: %s", err)
+	}
 
-		for _, candidate := range page.JSON200.CatalogEntries {
-			if candidate.Name == targetName {
-				return &candidate, nil
-			}
-			for _, alias := range candidate.Aliases {
-				if alias == targetName {
-					return &candidate, nil
-				}
-			}
-		}
+	return m.one()
+}
 
-		if count := len(page.JSON200.CatalogEntries); count == 0 {
-			return nil, fmt.Errorf("failed to find catalog entry %q", targetName) // end pagination
-		} else {
-			after = lo.ToPtr(page.JSON200.CatalogEntries[count-1].Id)
-		}
+// FindCatalogEntriesByName returns the entries of every catalog type whose name, alias
+// or external ID is targetName, ignoring case.
+func FindCatalogEntriesByName(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, targetName string) ([]client.CatalogEntryV2, error) {
+	m := newMatcher("catalog entry", targetName, describeCatalogEntry)
+
+	err := WalkAllCatalogEntries(ctx, logger, cl, defaultPageSize, func(candidate client.CatalogEntryV2) error {
+		m.add(candidate, catalogEntryNames(candidate)...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
+
+	return m.all()
+}
+
+// catalogEntryNames returns everything an entry can be referred to by.
+func catalogEntryNames(entry client.CatalogEntryV2) []string {
+	names := append([]string{entry.Name}, entry.Aliases...)
+	if entry.ExternalId != nil && *entry.ExternalId != "" {
+		names = append(names, *entry.ExternalId)
+	}
+	return names
+}
+
+func describeCatalogType(v client.CatalogTypeV2) string {
+	return fmt.Sprintf("%q (%s, %s)", v.Name, v.TypeName, v.Id)
+}
+
+func describeCatalogEntry(v client.CatalogEntryV2) string {
+	return fmt.Sprintf("%q (%s)", v.Name, v.Id)
 }
 
 func FindCatalogEntryByID(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, targetID string) (*client.CatalogEntryV2, error) {
@@ -114,58 +261,292 @@ func FindCatalogEntryByID(ctx context.Context, logger kitlog.Logger, cl *client.
 }
 
 func ListAllCustomFields(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) ([]client.CustomFieldV2, error) {
-	res, err := cl.CustomFieldsV2ListWithResponse(ctx)
+	return cached(logger, cacheKeyCustomFields, cacheTTLReferenceData, func() ([]client.CustomFieldV2, error) {
+		res, err := cl.CustomFieldsV2ListWithResponse(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "listing catalog")
+		}
+		return res.JSON200.CustomFields, nil
+	})
+}
+
+func ListAllCatalogTypes(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) ([]client.CatalogTypeV2, error) {
+	return cached(logger, cacheKeyCatalogTypes, cacheTTLReferenceData, func() ([]client.CatalogTypeV2, error) {
+		res, err := cl.CatalogV2ListTypesWithResponse(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "listing catalog types")
+		}
+		return res.JSON200.CatalogTypes, nil
+	})
+}
+
+// ListAllSeverities returns every severity, most severe first.
+func ListAllSeverities(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) ([]client.SeverityV2, error) {
+	severities, err := cached(logger, cacheKeySeverities, cacheTTLReferenceData, func() ([]client.SeverityV2, error) {
+		res, err := cl.SeveritiesV1ListWithResponse(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "listing severities")
+		}
+		return res.JSON200.Severities, nil
+	})
 	if err != nil {
-		return nil, errors.Wrap(err, "listing catalog")
+		return nil, err
 	}
-	return res.JSON200.CustomFields, nil
+
+	sort.SliceStable(severities, func(i, j int) bool { return severities[i].Rank > severities[j].Rank })
+	return severities, nil
 }
 
-func ListAllCatalogTypes(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) ([]client.CatalogTypeV2, error) {
-	res, err := cl.CatalogV2ListTypesWithResponse(ctx)
+// FindSeverity finds a severity by ID, or otherwise by name ignoring case.
+func FindSeverity(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, target string) (*client.SeverityV2, error) {
+	severities, err := ListAllSeverities(ctx, logger, cl)
 	if err != nil {
-		return nil, errors.Wrap(err, "listing catalog types")
+		return nil, err
+	}
+
+	m := newMatcher("severity", target, func(v client.SeverityV2) string {
+		return fmt.Sprintf("%q (%s)", v.Name, v.Id)
+	})
+	for _, v := range severities {
+		m.addWithID(v, v.Id, v.Name)
 	}
-	return res.JSON200.CatalogTypes, nil
+
+	return m.one()
 }
 
-func ListAllCatalogEntries(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) ([]client.CatalogEntryV2, error) {
-	catalogTypes, err := ListAllCatalogTypes(ctx, logger, cl)
+func CreateSeverity(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, body client.SeveritiesV1CreateJSONRequestBody) (*client.SeverityV2, error) {
+	defer responseCache.invalidate(cacheKeySeverities)
+
+	res, err := cl.SeveritiesV1CreateWithResponse(ctx, body)
 	if err != nil {
-		return nil, fmt.Errorf("failed enumerating catalog types: %s", err)
+		return nil, errors.Wrap(err, "creating severity")
 	}
+	return &res.JSON201.Severity, nil
+}
 
-	var results []client.CatalogEntryV2
+func UpdateSeverity(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, id string, body client.SeveritiesV1UpdateJSONRequestBody) (*client.SeverityV2, error) {
+	defer responseCache.invalidate(cacheKeySeverities)
 
-	for _, catalogType := range catalogTypes {
-		var (
-			after    *string
-			pageSize = 250
-		)
-
-		for {
-			page, err := cl.CatalogV2ListEntriesWithResponse(ctx, &client.CatalogV2ListEntriesParams{
-				CatalogTypeId: catalogType.Id,
-				PageSize:      &pageSize,
-				After:         after,
-			})
-			if err != nil {
-				return nil, fmt.Errorf("listing catalog entries: %q", err)
-			}
+	res, err := cl.SeveritiesV1UpdateWithResponse(ctx, id, body)
+	if err != nil {
+		return nil, errors.Wrap(err, "updating severity")
+	}
+	return &res.JSON200.Severity, nil
+}
 
-			results = append(results, page.JSON200.CatalogEntries...)
+func DeleteSeverity(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, id string) error {
+	defer responseCache.invalidate(cacheKeySeverities)
 
-			if count := len(page.JSON200.CatalogEntries); count == 0 {
-				break // end pagination
-			} else {
-				after = lo.ToPtr(page.JSON200.CatalogEntries[count-1].Id)
-			}
+	if _, err := cl.SeveritiesV1DeleteWithResponse(ctx, id); err != nil {
+		return errors.Wrap(err, "deleting severity")
+	}
+	return nil
+}
+
+// ListAllIncidentStatuses returns every incident status in lifecycle order.
+func ListAllIncidentStatuses(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) ([]client.IncidentStatusV1, error) {
+	statuses, err := cached(logger, cacheKeyIncidentStatuses, cacheTTLReferenceData, func() ([]client.IncidentStatusV1, error) {
+		res, err := cl.IncidentStatusesV1ListWithResponse(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "listing incident statuses")
+		}
+		return res.JSON200.IncidentStatuses, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(statuses, func(i, j int) bool { return statuses[i].Rank < statuses[j].Rank })
+	return statuses, nil
+}
+
+// FindIncidentStatus finds an incident status by ID, or otherwise by name ignoring case.
+func FindIncidentStatus(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, target string) (*client.IncidentStatusV1, error) {
+	statuses, err := ListAllIncidentStatuses(ctx, logger, cl)
+	if err != nil {
+		return nil, err
+	}
+
+	m := newMatcher("incident status", target, func(v client.IncidentStatusV1) string {
+		return fmt.Sprintf("%q (%s, %s)", v.Name, v.Category, v.Id)
+	})
+	for _, v := range statuses {
+		m.addWithID(v, v.Id, v.Name)
+	}
+
+	return m.one()
+}
+
+func CreateIncidentStatus(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, body client.IncidentStatusesV1CreateJSONRequestBody) (*client.IncidentStatusV1, error) {
+	defer responseCache.invalidate(cacheKeyIncidentStatuses)
+
+	res, err := cl.IncidentStatusesV1CreateWithResponse(ctx, body)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating incident status")
+	}
+	return &res.JSON201.IncidentStatus, nil
+}
+
+func UpdateIncidentStatus(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, id string, body client.IncidentStatusesV1UpdateJSONRequestBody) (*client.IncidentStatusV1, error) {
+	defer responseCache.invalidate(cacheKeyIncidentStatuses)
+
+	res, err := cl.IncidentStatusesV1UpdateWithResponse(ctx, id, body)
+	if err != nil {
+		return nil, errors.Wrap(err, "updating incident status")
+	}
+	return &res.JSON200.IncidentStatus, nil
+}
+
+func DeleteIncidentStatus(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, id string) error {
+	defer responseCache.invalidate(cacheKeyIncidentStatuses)
+
+	if _, err := cl.IncidentStatusesV1DeleteWithResponse(ctx, id); err != nil {
+		return errors.Wrap(err, "deleting incident status")
+	}
+	return nil
+}
+
+func ListAllIncidentRoles(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) ([]client.IncidentRoleV2, error) {
+	return cached(logger, cacheKeyIncidentRoles, cacheTTLReferenceData, func() ([]client.IncidentRoleV2, error) {
+		res, err := cl.IncidentRolesV2ListWithResponse(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "listing incident roles")
+		}
+		return res.JSON200.IncidentRoles, nil
+	})
+}
+
+// FindIncidentRole finds an incident role by ID, or otherwise by name or shortform
+// ignoring case.
+func FindIncidentRole(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, target string) (*client.IncidentRoleV2, error) {
+	roles, err := ListAllIncidentRoles(ctx, logger, cl)
+	if err != nil {
+		return nil, err
+	}
+
+	m := newMatcher("incident role", target, func(v client.IncidentRoleV2) string {
+		return fmt.Sprintf("%q (%s)", v.Name, v.Id)
+	})
+	for _, v := range roles {
+		m.addWithID(v, v.Id, v.Name, v.Shortform)
+	}
+
+	return m.one()
+}
+
+func CreateIncidentRole(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, body client.IncidentRolesV2CreateJSONRequestBody) (*client.IncidentRoleV2, error) {
+	defer responseCache.invalidate(cacheKeyIncidentRoles)
+
+	res, err := cl.IncidentRolesV2CreateWithResponse(ctx, body)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating incident role")
+	}
+	return &res.JSON201.IncidentRole, nil
+}
+
+func UpdateIncidentRole(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, id string, body client.IncidentRolesV2UpdateJSONRequestBody) (*client.IncidentRoleV2, error) {
+	defer responseCache.invalidate(cacheKeyIncidentRoles)
+
+	res, err := cl.IncidentRolesV2UpdateWithResponse(ctx, id, body)
+	if err != nil {
+		return nil, errors.Wrap(err, "updating incident role")
+	}
+	return &res.JSON200.IncidentRole, nil
+}
+
+func DeleteIncidentRole(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, id string) error {
+	defer responseCache.invalidate(cacheKeyIncidentRoles)
+
+	if _, err := cl.IncidentRolesV2DeleteWithResponse(ctx, id); err != nil {
+		return errors.Wrap(err, "deleting incident role")
+	}
+	return nil
+}
+
+func ListAllIncidentTypes(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) ([]client.IncidentTypeV1, error) {
+	return cached(logger, cacheKeyIncidentTypes, cacheTTLReferenceData, func() ([]client.IncidentTypeV1, error) {
+		res, err := cl.IncidentTypesV1ListWithResponse(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "listing incident types")
 		}
+		return res.JSON200.IncidentTypes, nil
+	})
+}
+
+// ListAllIncidentTimestamps returns the timestamps configured for incidents, such as
+// "Reported at", in the order incident.io shows them.
+func ListAllIncidentTimestamps(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) ([]client.IncidentTimestampV2, error) {
+	return cached(logger, cacheKeyIncidentTimestamps, cacheTTLReferenceData, func() ([]client.IncidentTimestampV2, error) {
+		res, err := cl.IncidentTimestampsV2ListWithResponse(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "listing incident timestamps")
+		}
+
+		timestamps := res.JSON200.IncidentTimestamps
+		sort.SliceStable(timestamps, func(i, j int) bool { return timestamps[i].Rank < timestamps[j].Rank })
+		return timestamps, nil
+	})
+}
+
+// FindIncidentType finds an incident type by ID, or otherwise by name ignoring case.
+func FindIncidentType(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, target string) (*client.IncidentTypeV1, error) {
+	incidentTypes, err := ListAllIncidentTypes(ctx, logger, cl)
+	if err != nil {
+		return nil, err
+	}
+
+	m := newMatcher("incident type", target, func(v client.IncidentTypeV1) string {
+		return fmt.Sprintf("%q (%s)", v.Name, v.Id)
+	})
+	for _, v := range incidentTypes {
+		m.addWithID(v, v.Id, v.Name)
+	}
+
+	return m.one()
+}
+
+func ListAllCatalogEntries(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) ([]client.CatalogEntryV2, error) {
+	var results []client.CatalogEntryV2
+
+	err := WalkAllCatalogEntries(ctx, logger, cl, defaultPageSize, func(entry client.CatalogEntryV2) error {
+		results = append(results, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return results, nil
 }
 
+// WalkAllCatalogEntries calls fn for every catalog entry of every catalog type, one page
+// at a time.
+func WalkAllCatalogEntries(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, pageSize int, fn func(client.CatalogEntryV2) error) error {
+	catalogTypes, err := ListAllCatalogTypes(ctx, logger, cl)
+	if err != nil {
+		return fmt.Errorf("failed enumerating catalog types: %s", err)
+	}
+
+	// The per-type walk swallows errStopWalk, so remember it to skip the remaining types.
+	var stopped bool
+	visit := func(entry client.CatalogEntryV2) error {
+		err := fn(entry)
+		stopped = errors.Is(err, errStopWalk)
+		return err
+	}
+
+	for _, catalogType := range catalogTypes {
+		if err := WalkCatalogEntriesByTypeID(ctx, logger, cl, catalogType.Id, pageSize, visit); err != nil {
+			return err
+		}
+		if stopped {
+			return nil
+		}
+	}
+
+	return nil
+}
+
 func ListAllCatalogEntriesByTypeName(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, typeName string) ([]client.CatalogEntryV2, error) {
 	catalogType, err := FindCatalogTypeByName(ctx, logger, cl, typeName)
 	if err != nil {
@@ -176,11 +557,23 @@ func ListAllCatalogEntriesByTypeName(ctx context.Context, logger kitlog.Logger,
 }
 
 func ListAllCatalogEntriesByTypeID(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, typeID string) ([]client.CatalogEntryV2, error) {
-	var (
-		after    *string
-		pageSize = 250
-		results  = []client.CatalogEntryV2{}
-	)
+	results := []client.CatalogEntryV2{}
+
+	err := WalkCatalogEntriesByTypeID(ctx, logger, cl, typeID, defaultPageSize, func(entry client.CatalogEntryV2) error {
+		results = append(results, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// WalkCatalogEntriesByTypeID calls fn for every entry of a catalog type, fetching one
+// page at a time. Returning errStopWalk from fn ends pagination early without error.
+func WalkCatalogEntriesByTypeID(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, typeID string, pageSize int, fn func(client.CatalogEntryV2) error) error {
+	var after *string
 
 	for {
 		page, err := cl.CatalogV2ListEntriesWithResponse(ctx, &client.CatalogV2ListEntriesParams{
@@ -189,13 +582,17 @@ func ListAllCatalogEntriesByTypeID(ctx context.Context, logger kitlog.Logger, cl
 			After:         after,
 		})
 		if err != nil {
-			return nil, fmt.Errorf("listing catalog entries: %q", err)
+			return fmt.Errorf("listing catalog entries: %q", err)
 		}
 
-		results = append(results, page.JSON200.CatalogEntries...)
+		for _, entry := range page.JSON200.CatalogEntries {
+			if err := fn(entry); err != nil {
+				return stopWalk(err)
+			}
+		}
 
 		if count := len(page.JSON200.CatalogEntries); count == 0 {
-			return results, nil // end pagination
+			return nil // end pagination
 		} else {
 			after = lo.ToPtr(page.JSON200.CatalogEntries[count-1].Id)
 		}
@@ -203,12 +600,12 @@ func ListAllCatalogEntriesByTypeID(ctx context.Context, logger kitlog.Logger, cl
 }
 
 func EditIncidentByReferenceNumber(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, reference int, newCustomFields map[string]string) (*client.IncidentV2, error) {
-	incident, err := FindIncidentByReferenceNumber(ctx, logger, cl, reference)
+	id, err := FindIncidentIDByReferenceNumber(ctx, logger, cl, reference)
 	if err != nil {
 		return nil, fmt.Errorf("finding incident by id to show: %q", err)
 	}
 
-	return EditIncident(ctx, logger, cl, incident.Id, newCustomFields)
+	return EditIncident(ctx, logger, cl, id, newCustomFields)
 }
 
 func ShowIncidentByID(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, id string) (*client.IncidentV2, error) {
@@ -219,117 +616,584 @@ func ShowIncidentByID(ctx context.Context, logger kitlog.Logger, cl *client.Clie
 	return &res.JSON200.Incident, nil
 }
 
+// ShowIncidentByReference fetches e.g. INC-123 given 123. The show endpoint accepts a
+// reference number in place of an ID, so incidents are only listed when it can't find
+// the incident.
 func ShowIncidentByReference(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, reference int) (*client.IncidentV2, error) {
-	incident, err := FindIncidentByReferenceNumber(ctx, logger, cl, reference)
-	if err != nil {
-		return nil, errors.Wrap(err, "finding incident by id to show")
+	target := fmt.Sprintf("INC-%d", reference)
+
+	references := map[string]string{}
+	if responseCache.get(cacheKeyIncidentReferences, cacheTTLIncidentReferences, &references) {
+		if id, ok := references[target]; ok {
+			incident, err := ShowIncidentByID(ctx, logger, cl, id)
+			if !client.IsNotFound(err) {
+				return incident, err
+			}
+
+			// the cached ID is stale, e.g. the incident was deleted, so look it up afresh
+			level.Debug(logger).Log("msg", "cached incident not found", "reference", target, "id", id)
+			delete(references, target)
+			if err := responseCache.put(cacheKeyIncidentReferences, references); err != nil {
+				logger.Log("msg", "failed to write cache", "key", cacheKeyIncidentReferences, "error", err)
+			}
+		}
+	}
+
+	incident, err := ShowIncidentByID(ctx, logger, cl, strconv.Itoa(reference))
+	if err != nil && !client.IsNotFound(err) {
+		return nil, errors.Wrap(err, "showing incident by reference")
 	}
 
-	return ShowIncidentByID(ctx, logger, cl, incident.Id)
+	if err != nil || incident.Reference != target {
+		level.Debug(logger).Log("msg", "show by reference failed, listing incidents", "reference", target)
+
+		incident, err = findIncidentInList(ctx, logger, cl, target, references)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	references[incident.Reference] = incident.Id
+	if err := responseCache.put(cacheKeyIncidentReferences, references); err != nil {
+		logger.Log("msg", "failed to write cache", "key", cacheKeyIncidentReferences, "error", err)
+	}
+
+	return incident, nil
 }
 
 func ListAllIncidents(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) ([]client.IncidentV2, error) {
-	var (
-		after    *string
-		pageSize = int64(250)
-		results  = []client.IncidentV2{}
-	)
+	results := []client.IncidentV2{}
+
+	err := WalkIncidents(ctx, logger, cl, client.IncidentsV2ListParams{}, func(incident client.IncidentV2) error {
+		results = append(results, incident)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// WalkIncidents calls fn for every incident matching params, fetching one page at a
+// time. params.After is managed by the walk; params.PageSize defaults to defaultPageSize.
+// Returning errStopWalk from fn ends pagination early without error.
+func WalkIncidents(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, params client.IncidentsV2ListParams, fn func(client.IncidentV2) error) error {
+	if params.PageSize == nil {
+		params.PageSize = lo.ToPtr(int64(defaultPageSize))
+	}
+	params.After = nil
+
+	// the generated client can't encode the filter maps, so they're moved onto the query
+	// by hand, e.g. as status_category[one_of]=live
+	filters := map[string]*map[string][]string{
+		"status":          params.Status,
+		"status_category": params.StatusCategory,
+		"severity":        params.Severity,
+		"incident_type":   params.IncidentType,
+	}
+	params.Status, params.StatusCategory, params.Severity, params.IncidentType = nil, nil, nil, nil
+
+	addFilters := func(ctx context.Context, req *http.Request) error {
+		query := req.URL.Query()
+		for name, filter := range filters {
+			for operator, values := range lo.FromPtr(filter) {
+				for _, value := range values {
+					query.Add(fmt.Sprintf("%s[%s]", name, operator), value)
+				}
+			}
+		}
+		req.URL.RawQuery = query.Encode()
+		return nil
+	}
 
 	for {
-		page, err := cl.IncidentsV2ListWithResponse(ctx, &client.IncidentsV2ListParams{
-			PageSize: &pageSize,
-			After:    after,
-		})
+		page, err := cl.IncidentsV2ListWithResponse(ctx, &params, addFilters)
 		if err != nil {
-			return nil, errors.Wrap(err, "listing incidents")
+			return errors.Wrap(err, "listing incidents")
 		}
 
-		results = append(results, page.JSON200.Incidents...)
+		for _, incident := range page.JSON200.Incidents {
+			if err := fn(incident); err != nil {
+				return stopWalk(err)
+			}
+		}
 
 		if count := len(page.JSON200.Incidents); count == 0 {
-			return results, nil // end pagination
+			return nil // end pagination
 		} else {
-			after = lo.ToPtr(page.JSON200.Incidents[count-1].Id)
+			params.After = lo.ToPtr(page.JSON200.Incidents[count-1].Id)
 		}
 	}
 }
 
 func FindIncidentByReferenceNumber(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, reference int) (*client.IncidentV2, error) {
-	incidents, err := ListAllIncidents(ctx, logger, cl)
+	return ShowIncidentByReference(ctx, logger, cl, reference)
+}
+
+// FindIncidentIDByReferenceNumber resolves e.g. 123 to the ID of INC-123, without any
+// request when the reference is in the cached reference map.
+func FindIncidentIDByReferenceNumber(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, reference int) (string, error) {
+	references := map[string]string{}
+	if responseCache.get(cacheKeyIncidentReferences, cacheTTLIncidentReferences, &references) {
+		if id, ok := references[fmt.Sprintf("INC-%d", reference)]; ok {
+			return id, nil
+		}
+	}
+
+	incident, err := ShowIncidentByReference(ctx, logger, cl, reference)
 	if err != nil {
-		return nil, errors.Wrap(err, "listing incidents to find by reference")
+		return "", err
+	}
+
+	return incident.Id, nil
+}
+
+// FindIncident shows the incident given as an ID, a reference such as INC-123, or a
+// permalink.
+func FindIncident(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, identifier string) (*client.IncidentV2, error) {
+	id, reference, err := ParseIncidentIdentifier(identifier)
+	if err != nil {
+		return nil, err
 	}
 
-	for _, candidate := range incidents {
-		if candidate.Reference == fmt.Sprintf("INC-%d", reference) {
-			return &candidate, nil
+	if id != "" {
+		return ShowIncidentByID(ctx, logger, cl, id)
+	}
+
+	return ShowIncidentByReference(ctx, logger, cl, reference)
+}
+
+// FindIncidentID returns the ID of the incident given as an ID, a reference such as
+// INC-123, or a permalink.
+func FindIncidentID(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, identifier string) (string, error) {
+	id, reference, err := ParseIncidentIdentifier(identifier)
+	if err != nil {
+		return "", err
+	}
+
+	if id != "" {
+		return id, nil
+	}
+
+	return FindIncidentIDByReferenceNumber(ctx, logger, cl, reference)
+}
+
+// incidentReferenceShowLimit is how many incidents missing from the cached reference map
+// IncidentReferencesByID shows one at a time. Beyond it, listing every incident once is
+// fewer requests.
+const incidentReferenceShowLimit = 10
+
+// IncidentReferencesByID returns the reference, e.g. INC-123, of each of the given
+// incident IDs. Those missing from the cache are shown one at a time when there are
+// few, otherwise every incident is listed once, which fills the whole cached map.
+func IncidentReferencesByID(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, ids []string) (map[string]string, error) {
+	references := map[string]string{}
+	responseCache.get(cacheKeyIncidentReferences, cacheTTLIncidentReferences, &references)
+
+	results := lo.Invert(references)
+	missing := lo.Filter(lo.Uniq(ids), func(id string, _ int) bool { _, ok := results[id]; return !ok })
+	if len(missing) == 0 {
+		return results, nil
+	}
+
+	if len(missing) > incidentReferenceShowLimit {
+		err := WalkIncidents(ctx, logger, cl, client.IncidentsV2ListParams{}, func(incident client.IncidentV2) error {
+			results[incident.Id] = incident.Reference
+			references[incident.Reference] = incident.Id
+			return nil
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "listing incidents to find their references")
 		}
+
+		// anything the list leaves out is still shown one at a time
+		missing = lo.Filter(missing, func(id string, _ int) bool { _, ok := results[id]; return !ok })
 	}
 
-	return nil, errors.New("incident not found")
+	for _, id := range missing {
+		incident, err := ShowIncidentByID(ctx, logger, cl, id)
+		if err != nil {
+			return nil, errors.Wrapf(err, "finding reference of incident %s", id)
+		}
+		results[id] = incident.Reference
+		references[incident.Reference] = id
+	}
+
+	if err := responseCache.put(cacheKeyIncidentReferences, references); err != nil {
+		logger.Log("msg", "failed to write cache", "key", cacheKeyIncidentReferences, "error", err)
+	}
+
+	return results, nil
+}
+
+// findIncidentInList lists incidents until one has the target reference, remembering
+// every reference seen along the way in references.
+func findIncidentInList(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, target string, references map[string]string) (*client.IncidentV2, error) {
+	var match *client.IncidentV2
+
+	err := WalkIncidents(ctx, logger, cl, client.IncidentsV2ListParams{}, func(candidate client.IncidentV2) error {
+		references[candidate.Reference] = candidate.Id
+		if candidate.Reference == target {
+			match = &candidate
+			return errStopWalk
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "listing incidents to find by reference")
+	}
+
+	if match == nil {
+		return nil, errors.New("incident not found")
+	}
+
+	return match, nil
 }
 
 func EditIncident(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, id string, newCustomFields map[string]string) (*client.IncidentV2, error) {
-	customFields, err := ListAllCustomFields(ctx, logger, cl)
+	resolver, err := newCustomFieldResolver(ctx, logger, cl)
 	if err != nil {
-		return nil, fmt.Errorf("failed to find custom field types: %q", err)
+		return nil, err
 	}
 
-	customFieldIDToType := map[string]client.CustomFieldV2FieldType{}
-	customFieldMap := map[string]string{}
-	for _, candidate := range customFields {
-		customFieldMap[candidate.Name] = candidate.Id
-		customFieldIDToType[candidate.Id] = candidate.FieldType
+	entries, err := resolver.entries(ctx, logger, cl, newCustomFields)
+	if err != nil {
+		return nil, err
 	}
 
-	body := client.IncidentsV2EditJSONRequestBody{
-		Incident: client.IncidentEditPayloadV2{
-			CustomFieldEntries: &[]client.CustomFieldEntryPayloadV1{},
-		},
-		NotifyIncidentChannel: false,
+	return UpdateIncident(ctx, logger, cl, id, client.IncidentEditPayloadV2{CustomFieldEntries: &entries}, false)
+}
+
+// customFieldResolver turns custom field names and values into the entries of an edit
+// payload, remembering every value it looked up so bulk edits resolve each only once.
+type customFieldResolver struct {
+	fields []client.CustomFieldV2
+	values map[string]client.CustomFieldValuePayloadV1
+}
+
+func newCustomFieldResolver(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) (*customFieldResolver, error) {
+	fields, err := ListAllCustomFields(ctx, logger, cl)
+	if err != nil {
+		return nil, errors.Wrap(err, "listing custom fields")
 	}
 
-	for k, v := range newCustomFields {
-		id, ok := customFieldMap[k]
-		if !ok {
-			return nil, errors.Errorf("custom field ID for %q not found", k)
+	return &customFieldResolver{fields: fields, values: map[string]client.CustomFieldValuePayloadV1{}}, nil
+}
+
+// entries returns an entry setting each named custom field to its value, where an
+// empty value clears the field.
+func (r *customFieldResolver) entries(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, values map[string]string) ([]client.CustomFieldEntryPayloadV1, error) {
+	names := lo.Keys(values)
+	sort.Strings(names)
+
+	entries := []client.CustomFieldEntryPayloadV1{}
+	for _, name := range names {
+		m := newMatcher("custom field", name, func(v client.CustomFieldV2) string {
+			return fmt.Sprintf("%q (%s)", v.Name, v.Id)
+		})
+		for _, v := range r.fields {
+			m.addWithID(v, v.Id, v.Name)
+		}
+
+		field, err := m.one()
+		if err != nil {
+			return nil, err
 		}
 
 		// empty array resets previous set value
-		var values = []client.CustomFieldValuePayloadV1{}
-
-		if v != "" {
-			var customVal client.CustomFieldValuePayloadV1
-			switch customFieldIDToType[id] {
-			case client.SingleSelect:
-				customVal = client.CustomFieldValuePayloadV1{
-					ValueCatalogEntryId: &v,
-				}
+		entry := client.CustomFieldEntryPayloadV1{CustomFieldId: field.Id, Values: []client.CustomFieldValuePayloadV1{}}
+		if value := values[name]; value != "" {
+			v, err := r.value(ctx, logger, cl, *field, value)
+			if err != nil {
+				return nil, errors.Wrapf(err, "resolving value of custom field %q", field.Name)
+			}
+			entry.Values = append(entry.Values, v)
+		}
+
+		entries = append(entries, entry)
+	}
 
-			case client.Text:
-				customVal = client.CustomFieldValuePayloadV1{
-					ValueText: &v,
+	return entries, nil
+}
+
+// value resolves a select value, given as the name, alias or ID of a catalog entry or
+// the value or ID of an option, to the ID the API expects.
+func (r *customFieldResolver) value(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, field client.CustomFieldV2, value string) (client.CustomFieldValuePayloadV1, error) {
+	key := field.Id + "=" + value
+	if v, ok := r.values[key]; ok {
+		return v, nil
+	}
+
+	var v client.CustomFieldValuePayloadV1
+	switch field.FieldType {
+	case client.Text:
+		v.ValueText = &value
+	case client.Link:
+		v.ValueLink = &value
+	case client.Numeric:
+		v.ValueNumeric = &value
+	case client.SingleSelect:
+		if field.CatalogTypeId != nil {
+			id := value
+			if !IsULID(value) {
+				entry, err := FindCatalogEntryByNameWithTypeID(ctx, logger, cl, value, *field.CatalogTypeId)
+				if err != nil {
+					return v, err
 				}
-			default:
-				return nil, errors.Errorf("unsupported custom field type %q", customFieldIDToType[id])
+				id = entry.Id
+			}
+			v.ValueCatalogEntryId = &id
+		} else {
+			option, err := FindCustomFieldOption(ctx, logger, cl, field.Id, value)
+			if err != nil {
+				return v, err
 			}
+			v.ValueOptionId = &option.Id
+		}
+	default:
+		return v, errors.Errorf("unsupported custom field type %q", field.FieldType)
+	}
+
+	r.values[key] = v
+	return v, nil
+}
+
+// ListAllFollowUps returns the follow-ups of one incident, or of every incident when
+// params has no incident ID.
+func ListAllFollowUps(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, params client.FollowUpsV2ListParams) ([]client.FollowUpV2, error) {
+	res, err := cl.FollowUpsV2ListWithResponse(ctx, &params)
+	if err != nil {
+		return nil, errors.Wrap(err, "listing follow-ups")
+	}
+	return res.JSON200.FollowUps, nil
+}
+
+// ListAllActions returns the actions of one incident, or of every incident when params
+// has no incident ID.
+func ListAllActions(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, params client.ActionsV2ListParams) ([]client.ActionV2, error) {
+	res, err := cl.ActionsV2ListWithResponse(ctx, &params)
+	if err != nil {
+		return nil, errors.Wrap(err, "listing actions")
+	}
+	return res.JSON200.Actions, nil
+}
+
+// ListAllUsers returns every user of the organisation.
+func ListAllUsers(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) ([]client.UserV1, error) {
+	return cached(logger, cacheKeyUsers, cacheTTLReferenceData, func() ([]client.UserV1, error) {
+		return fetchAllUsers(ctx, logger, cl)
+	})
+}
+
+func fetchAllUsers(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) ([]client.UserV1, error) {
+	results := []client.UserV1{}
+	params := &client.UsersV2ListParams{PageSize: lo.ToPtr(defaultPageSize)}
 
-			values = append(values, customVal)
+	for {
+		res, err := cl.UsersV2ListWithResponse(ctx, params)
+		if err != nil {
+			return nil, errors.Wrap(err, "listing users")
 		}
 
-		*body.Incident.CustomFieldEntries = append(*body.Incident.CustomFieldEntries, client.CustomFieldEntryPayloadV1{
-			CustomFieldId: id,
-			Values:        values,
-		})
+		results = append(results, res.JSON200.Users...)
+
+		after := res.JSON200.PaginationMeta.After
+		if after == nil || *after == "" || len(res.JSON200.Users) == 0 {
+			return results, nil
+		}
+		params.After = after
+	}
+}
+
+func ShowUser(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, id string) (*client.UserV1, error) {
+	res, err := cl.UsersV2ShowWithResponse(ctx, id)
+	if err != nil {
+		return nil, errors.Wrap(err, "showing user")
+	}
+	return &res.JSON200.User, nil
+}
+
+// FindUser finds a user by ID, or otherwise by email address, Slack user ID or name
+// ignoring case. Users who joined since the cached list was fetched are found by
+// fetching it again.
+func FindUser(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, target string) (*client.UserV1, error) {
+	if IsULID(target) {
+		return ShowUser(ctx, logger, cl, target)
+	}
+
+	fetched := false
+	users, err := cached(logger, cacheKeyUsers, cacheTTLReferenceData, func() ([]client.UserV1, error) {
+		fetched = true
+		return fetchAllUsers(ctx, logger, cl)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := findUserInList(users, target)
+	if !isNotFoundError(err) || fetched {
+		return user, err
+	}
+
+	responseCache.invalidate(cacheKeyUsers)
+	if users, err = ListAllUsers(ctx, logger, cl); err != nil {
+		return nil, err
+	}
+	return findUserInList(users, target)
+}
+
+func findUserInList(users []client.UserV1, target string) (*client.UserV1, error) {
+	m := newMatcher("user", target, describeUser)
+	for _, v := range users {
+		m.addWithID(v, v.Id, lo.FromPtr(v.Email), lo.FromPtr(v.SlackUserId), v.Name)
+	}
+
+	return m.one()
+}
+
+func describeUser(v client.UserV1) string {
+	if v.Email != nil {
+		return fmt.Sprintf("%q <%s> (%s)", v.Name, *v.Email, v.Id)
 	}
+	return fmt.Sprintf("%q (%s)", v.Name, v.Id)
+}
+
+func AddIncidentMember(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, incidentID, userID string) (*client.IncidentMembership, error) {
+	res, err := cl.IncidentMembershipsV1CreateWithResponse(ctx, client.IncidentMembershipsV1CreateJSONRequestBody{
+		IncidentId: incidentID,
+		UserId:     userID,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "creating incident membership")
+	}
+	return &res.JSON201.IncidentMembership, nil
+}
+
+func RevokeIncidentMember(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, incidentID, userID string) error {
+	if _, err := cl.IncidentMembershipsV1RevokeWithResponse(ctx, client.IncidentMembershipsV1RevokeJSONRequestBody{
+		IncidentId: incidentID,
+		UserId:     userID,
+	}); err != nil {
+		return errors.Wrap(err, "revoking incident membership")
+	}
+	return nil
+}
+
+// ListAllIncidentUpdates returns the status and severity updates posted to an incident.
+func ListAllIncidentUpdates(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, incidentID string) ([]client.IncidentUpdateV2, error) {
+	results := []client.IncidentUpdateV2{}
+	params := &client.IncidentUpdatesV2ListParams{
+		IncidentId: &incidentID,
+		PageSize:   lo.ToPtr(defaultPageSize),
+	}
+
+	for {
+		res, err := cl.IncidentUpdatesV2ListWithResponse(ctx, params)
+		if err != nil {
+			return nil, errors.Wrap(err, "listing incident updates")
+		}
 
-	litter.Dump(id)
-	litter.Dump("===")
-	litter.Dump(body)
+		results = append(results, res.JSON200.IncidentUpdates...)
 
-	res, err := cl.IncidentsV2EditWithResponse(ctx, id, body)
+		meta := res.JSON200.PaginationMeta
+		if meta == nil || meta.After == nil || *meta.After == "" || len(res.JSON200.IncidentUpdates) == 0 {
+			return results, nil
+		}
+		params.After = meta.After
+	}
+}
+
+// UpdateIncident edits an incident, leaving every field unset in payload unchanged.
+func UpdateIncident(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, id string, payload client.IncidentEditPayloadV2, notify bool) (*client.IncidentV2, error) {
+	res, err := cl.IncidentsV2EditWithResponse(ctx, id, client.IncidentsV2EditJSONRequestBody{
+		Incident:              payload,
+		NotifyIncidentChannel: notify,
+	})
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to edit incident")
+		return nil, errors.Wrap(err, "editing incident")
 	}
 	return &res.JSON200.Incident, nil
 }
+
+// ListIncidentAttachments returns the attachments matching params, e.g. those of one
+// incident.
+func ListIncidentAttachments(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, params client.IncidentAttachmentsV1ListParams) ([]client.IncidentAttachmentV1, error) {
+	res, err := cl.IncidentAttachmentsV1ListWithResponse(ctx, &params)
+	if err != nil {
+		return nil, errors.Wrap(err, "listing incident attachments")
+	}
+	return res.JSON200.IncidentAttachments, nil
+}
+
+// FindIncidentAttachment finds an attachment of an incident by ID, or otherwise by the
+// external ID or permalink of the attached resource.
+func FindIncidentAttachment(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, incidentID, target string) (*client.IncidentAttachmentV1, error) {
+	attachments, err := ListIncidentAttachments(ctx, logger, cl, client.IncidentAttachmentsV1ListParams{IncidentId: &incidentID})
+	if err != nil {
+		return nil, err
+	}
+
+	m := newMatcher("attachment", target, func(v client.IncidentAttachmentV1) string {
+		return fmt.Sprintf("%s %q (%s)", v.Resource.ResourceType, v.Resource.ExternalId, v.Id)
+	})
+	for _, v := range attachments {
+		m.addWithID(v, v.Id, v.Resource.ExternalId, v.Resource.Permalink)
+	}
+
+	return m.one()
+}
+
+func CreateIncidentAttachment(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, incidentID string, resourceType client.CreateRequestBody4ResourceResourceType, externalID string) (*client.IncidentAttachmentV1, error) {
+	body := client.IncidentAttachmentsV1CreateJSONRequestBody{IncidentId: incidentID}
+	body.Resource.ResourceType = resourceType
+	body.Resource.ExternalId = externalID
+
+	res, err := cl.IncidentAttachmentsV1CreateWithResponse(ctx, body)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating incident attachment")
+	}
+	return &res.JSON201.IncidentAttachment, nil
+}
+
+func DeleteIncidentAttachment(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, id string) error {
+	if _, err := cl.IncidentAttachmentsV1DeleteWithResponse(ctx, id); err != nil {
+		return errors.Wrap(err, "deleting incident attachment")
+	}
+	return nil
+}
+
+// GetIdentity returns the name of the API key in use and the roles granted to it.
+func GetIdentity(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) (*client.IdentityV1, error) {
+	res, err := cl.UtilitiesV1IdentityWithResponse(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "showing identity")
+	}
+	return &res.JSON200.Identity, nil
+}
+
+// RequireRoles checks the API key has been granted every one of roles, so a command can
+// fail before it changes anything. Roles granted since the identity was cached are
+// found by fetching it again.
+func RequireRoles(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, roles ...client.IdentityV1Roles) error {
+	fetched := false
+	identity, err := cached(logger, cacheKeyIdentity, cacheTTLReferenceData, func() (*client.IdentityV1, error) {
+		fetched = true
+		return GetIdentity(ctx, logger, cl)
+	})
+	if err != nil {
+		return errors.Wrap(err, "checking the roles of the API key")
+	}
+
+	missing := lo.Without(roles, identity.Roles...)
+	if len(missing) == 0 {
+		return nil
+	}
+
+	if !fetched {
+		responseCache.invalidate(cacheKeyIdentity)
+		return RequireRoles(ctx, logger, cl, roles...)
+	}
+
+	return fmt.Errorf("API key %q is missing the %v role(s) needed for this command, an admin can grant them in the API keys settings", identity.Name, missing)
+}
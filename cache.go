@@ -0,0 +1,198 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	kitlog "github.com/go-kit/log"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// Cache keys and how long their values stay fresh. Reference data changes rarely, and
+// an incident reference never points at a different incident once assigned.
+const (
+	cacheKeyCustomFields       = "custom-fields"
+	cacheKeyCatalogTypes       = "catalog-types"
+	cacheKeySeverities         = "severities"
+	cacheKeyIncidentStatuses   = "incident-statuses"
+	cacheKeyIncidentRoles      = "incident-roles"
+	cacheKeyIncidentTypes      = "incident-types"
+	cacheKeyIncidentTimestamps = "incident-timestamps"
+	cacheKeyUsers              = "users"
+	cacheKeyIdentity           = "identity"
+	cacheKeyIncidentReferences = "incident-references"
+
+	cacheTTLReferenceData      = time.Hour
+	cacheTTLIncidentReferences = 30 * 24 * time.Hour
+)
+
+// noCache is bound to the root --no-cache flag.
+var noCache bool
+
+// responseCache is configured by setup for the API key in use. A nil cache is valid and
+// never stores anything.
+var responseCache *diskCache
+
+// diskCache stores JSON encoded API responses under the user's cache directory, one file
+// per key, namespaced by a hash of the API key so organisations never share entries.
+type diskCache struct {
+	dir string
+	mu  sync.Mutex
+}
+
+type diskCacheEntry struct {
+	StoredAt time.Time       `json:"stored_at"`
+	Value    json.RawMessage `json:"value"`
+}
+
+func cacheRootDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", errors.Wrap(err, "finding user cache directory")
+	}
+	return filepath.Join(dir, "inc"), nil
+}
+
+func newDiskCache(apiKey string) (*diskCache, error) {
+	root, err := cacheRootDir()
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256([]byte(apiKey))
+	return &diskCache{dir: filepath.Join(root, hex.EncodeToString(sum[:8]))}, nil
+}
+
+func (c *diskCache) path(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
+
+// get decodes the value stored under key into out, reporting whether a value younger
+// than ttl was found.
+func (c *diskCache) get(key string, ttl time.Duration, out any) bool {
+	if c == nil {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return false
+	}
+
+	var entry diskCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || time.Since(entry.StoredAt) > ttl {
+		return false
+	}
+
+	return json.Unmarshal(entry.Value, out) == nil
+}
+
+func (c *diskCache) put(key string, value any) error {
+	if c == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return errors.Wrap(err, "encoding cache value")
+	}
+
+	data, err := json.Marshal(diskCacheEntry{StoredAt: time.Now(), Value: raw})
+	if err != nil {
+		return errors.Wrap(err, "encoding cache entry")
+	}
+
+	if err := os.MkdirAll(c.dir, 0o700); err != nil {
+		return errors.Wrap(err, "creating cache directory")
+	}
+
+	// write then rename, so concurrent invocations never read a partial file
+	tmp, err := os.CreateTemp(c.dir, key+".*.tmp")
+	if err != nil {
+		return errors.Wrap(err, "creating cache file")
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "writing cache file")
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrap(err, "writing cache file")
+	}
+
+	return errors.Wrap(os.Rename(tmp.Name(), c.path(key)), "replacing cache file")
+}
+
+// invalidate drops the given keys, e.g. after a command changed the data behind them.
+func (c *diskCache) invalidate(keys ...string) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range keys {
+		os.Remove(c.path(key))
+	}
+}
+
+// cached returns the value stored under key if it is younger than ttl, otherwise it
+// calls fetch and stores the result. Failing to write the cache is logged, not returned.
+func cached[T any](logger kitlog.Logger, key string, ttl time.Duration, fetch func() (T, error)) (T, error) {
+	var value T
+	if responseCache.get(key, ttl, &value) {
+		return value, nil
+	}
+
+	value, err := fetch()
+	if err != nil {
+		return value, err
+	}
+
+	if err := responseCache.put(key, value); err != nil {
+		logger.Log("msg", "failed to write cache", "key", key, "error", err)
+	}
+
+	return value, nil
+}
+
+func NewCacheCommand() *cobra.Command {
+	root := &cobra.Command{
+		Use:   "cache",
+		Short: "manage the local cache of reference data",
+	}
+
+	root.AddCommand(&cobra.Command{
+		Use:   "clear",
+		Short: "remove all cached data, for every API key",
+		Run: func(cmd *cobra.Command, args []string) {
+			dir, err := cacheRootDir()
+			if err != nil {
+				fmt.Printf("failed to clear cache: %s", err)
+				os.Exit(1)
+			}
+
+			if err := os.RemoveAll(dir); err != nil {
+				fmt.Printf("failed to clear cache: %s", err)
+				os.Exit(1)
+			}
+		},
+	})
+
+	return root
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/alexeldeib/incli/client"
+	kitlog "github.com/go-kit/log"
+	"github.com/samber/lo"
+)
+
+// resolvedCatalogEntry is a catalog entry with its attributes keyed by name rather than
+// ID, and references to other entries replaced by their names. Attributes hold a string,
+// or a list of strings for array attributes.
+type resolvedCatalogEntry struct {
+	Id            string         `json:"id"`
+	Name          string         `json:"name"`
+	CatalogTypeId string         `json:"catalog_type_id"`
+	CatalogType   string         `json:"catalog_type"`
+	ExternalId    *string        `json:"external_id,omitempty"`
+	Aliases       []string       `json:"aliases"`
+	Rank          int32          `json:"rank"`
+	Attributes    map[string]any `json:"attributes"`
+	ArchivedAt    *time.Time     `json:"archived_at,omitempty"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+}
+
+// catalogResolver labels catalog entry attributes using their types' schemas. Entry
+// names are remembered, so each referenced entry is only fetched once.
+type catalogResolver struct {
+	types       map[string]client.CatalogTypeV2
+	typesByName map[string]client.CatalogTypeV2
+	entryNames  map[string]string
+}
+
+func newCatalogResolver(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) (*catalogResolver, error) {
+	catalogTypes, err := ListAllCatalogTypes(ctx, logger, cl)
+	if err != nil {
+		return nil, err
+	}
+
+	return &catalogResolver{
+		types:       lo.KeyBy(catalogTypes, func(v client.CatalogTypeV2) string { return v.Id }),
+		typesByName: lo.KeyBy(catalogTypes, func(v client.CatalogTypeV2) string { return v.TypeName }),
+		entryNames:  map[string]string{},
+	}, nil
+}
+
+// remember records an entry's name, saving a lookup when it is referenced later.
+func (r *catalogResolver) remember(entry client.CatalogEntryV2) {
+	r.entryNames[entry.Id] = entry.Name
+}
+
+// references returns the IDs of the entries an attribute value refers to, or nil when
+// the attribute isn't a reference to another catalog type.
+func (r *catalogResolver) references(attribute client.CatalogTypeAttributeV2, binding client.EngineParamBindingV2) []string {
+	_, isReference := r.typesByName[attribute.Type]
+
+	var ids []string
+	for _, value := range catalogBindingValues(binding) {
+		switch {
+		case value.CatalogEntry != nil:
+			ids = append(ids, value.CatalogEntry.CatalogEntryId)
+		case isReference && value.Literal != nil:
+			ids = append(ids, *value.Literal)
+		}
+	}
+	return ids
+}
+
+// attributes returns an entry's attributes in schema order, skipping those not set.
+func (r *catalogResolver) attributes(entry client.CatalogEntryV2) []client.CatalogTypeAttributeV2 {
+	var attributes []client.CatalogTypeAttributeV2
+	for _, attribute := range r.types[entry.CatalogTypeId].Schema.Attributes {
+		if _, ok := entry.AttributeValues[attribute.Id]; ok {
+			attributes = append(attributes, attribute)
+		}
+	}
+
+	// values of attributes since removed from the schema are kept, under their ID
+	for id := range entry.AttributeValues {
+		if !lo.ContainsBy(attributes, func(v client.CatalogTypeAttributeV2) bool { return v.Id == id }) {
+			attributes = append(attributes, client.CatalogTypeAttributeV2{Id: id, Name: id})
+		}
+	}
+	return attributes
+}
+
+func (r *catalogResolver) resolve(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, entry client.CatalogEntryV2) (resolvedCatalogEntry, error) {
+	r.remember(entry)
+
+	resolved := resolvedCatalogEntry{
+		Id:            entry.Id,
+		Name:          entry.Name,
+		CatalogTypeId: entry.CatalogTypeId,
+		CatalogType:   r.types[entry.CatalogTypeId].Name,
+		ExternalId:    entry.ExternalId,
+		Aliases:       entry.Aliases,
+		Rank:          entry.Rank,
+		Attributes:    map[string]any{},
+		ArchivedAt:    entry.ArchivedAt,
+		CreatedAt:     entry.CreatedAt,
+		UpdatedAt:     entry.UpdatedAt,
+	}
+
+	for _, attribute := range r.attributes(entry) {
+		binding := entry.AttributeValues[attribute.Id]
+
+		var values []string
+		if ids := r.references(attribute, binding); ids != nil {
+			for _, id := range ids {
+				name, err := r.entryName(ctx, logger, cl, id)
+				if err != nil {
+					return resolved, err
+				}
+				values = append(values, name)
+			}
+		} else {
+			values = lo.Map(catalogBindingValues(binding), func(v client.EngineParamBindingValueV2, _ int) string {
+				return lo.FromPtrOr(v.Literal, lo.FromPtrOr(v.Reference, v.Label))
+			})
+		}
+
+		if attribute.Array || binding.ArrayValue != nil {
+			resolved.Attributes[attribute.Name] = lo.Ternary(values == nil, []string{}, values)
+		} else if len(values) > 0 {
+			resolved.Attributes[attribute.Name] = values[0]
+		}
+	}
+
+	return resolved, nil
+}
+
+// entryName looks up the name of a referenced entry, falling back to its ID when it no
+// longer exists.
+func (r *catalogResolver) entryName(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, id string) (string, error) {
+	if name, ok := r.entryNames[id]; ok {
+		return name, nil
+	}
+
+	entry, err := FindCatalogEntryByID(ctx, logger, cl, id)
+	if client.IsNotFound(err) {
+		r.entryNames[id] = id
+		return id, nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	r.remember(*entry)
+	return entry.Name, nil
+}
+
+// catalogBindingValues returns the values of an attribute, whether it is an array or not.
+func catalogBindingValues(binding client.EngineParamBindingV2) []client.EngineParamBindingValueV2 {
+	if binding.ArrayValue != nil {
+		return *binding.ArrayValue
+	}
+	if binding.Value != nil {
+		return []client.EngineParamBindingValueV2{*binding.Value}
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/alexeldeib/incli/client"
 	kitlog "github.com/go-kit/log"
@@ -13,9 +14,23 @@ import (
 func NewGetCatalogEntriesCommand() *cobra.Command {
 	opts := &GetCatalogEntriesOptions{}
 	cmd := &cobra.Command{
-		Use:   "get",
+		Use:   "get [TYPE/NAME|NAME|ID]",
 		Short: "get one, many, or all catalog entries, by name/id with or without type name/id",
+		Long: `get one, many, or all catalog entries, by name/id with or without type name/id.
+
+An entry may be given as the only argument instead of flags: an ID, a name, alias or
+external ID searched across all types, or TYPE/NAME such as Service/checkout-api.
+
+Attributes are shown by name, with references to other catalog entries replaced by the
+entries' names, each referenced entry being fetched once. --raw shows them as the API
+returns them instead, keyed by attribute ID.`,
+		Args: cobra.MaximumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
+			if err := opts.Complete(args); err != nil {
+				fmt.Printf("invalid arguments: %s", err)
+				os.Exit(1)
+			}
+
 			ctx, logger, cl, err := setup()
 			if err != nil {
 				fmt.Printf("failed to setup: %s", err)
@@ -32,7 +47,11 @@ func NewGetCatalogEntriesCommand() *cobra.Command {
 	cmd.Flags().StringVarP(&opts.catalogTypeID, "type-id", "t", "", "catalog type id, e.g. 01HE6...")
 	cmd.Flags().StringVar(&opts.catalogTypeName, "type-name", "", "catalog type name, e.g. PagerdutyService")
 	cmd.Flags().StringVarP(&opts.catalogEntryName, "name", "n", "", "name or alias of custom catalog entry, e.g. Serving Infra Default")
-	cmd.Flags().StringVar(&opts.catalogEntryID, "id", "", "custom field to patch, e.g. --field foo=bar --field baz=qux. --field foo=bar=baz sets field `foo` to `bar=baz`")
+	cmd.Flags().StringVar(&opts.catalogEntryID, "id", "", "catalog entry id, e.g. 01HE6...")
+	cmd.Flags().StringVarP(&opts.output, "output", "o", outputJSON, "output format, one of: json, jsonl. jsonl prints each entry as soon as it is fetched")
+	cmd.Flags().IntVar(&opts.limit, "limit", 0, "maximum number of entries to list, 0 for no limit")
+	cmd.Flags().IntVar(&opts.pageSize, "page-size", defaultPageSize, "number of entries to fetch per API request")
+	cmd.Flags().BoolVar(&opts.raw, "raw", false, "show attribute values as the API returns them, keyed by attribute ID")
 
 	return cmd
 }
@@ -42,6 +61,52 @@ type GetCatalogEntriesOptions struct {
 	catalogTypeID    string
 	catalogEntryName string
 	catalogEntryID   string
+	catalogEntryArg  string
+	output           string
+	limit            int
+	pageSize         int
+	raw              bool
+}
+
+func (o *GetCatalogEntriesOptions) Complete(args []string) error {
+	if len(args) == 0 {
+		return nil
+	}
+
+	if o.catalogTypeName != "" || o.catalogTypeID != "" || o.catalogEntryName != "" || o.catalogEntryID != "" {
+		return fmt.Errorf("a positional identifier can't be combined with --id, --name, --type-id or --type-name")
+	}
+
+	if IsULID(args[0]) {
+		o.catalogEntryID = args[0]
+	} else {
+		o.catalogEntryArg = args[0]
+	}
+
+	return nil
+}
+
+// resolveEntryArg splits a positional TYPE/NAME into the type and entry name. Entry
+// names often contain slashes themselves, e.g. GitHub repositories, so when the part
+// before the first slash isn't a catalog type the whole argument is used as the name.
+func (o *GetCatalogEntriesOptions) resolveEntryArg(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) error {
+	typeName, entryName, ok := strings.Cut(o.catalogEntryArg, "/")
+	if !ok {
+		o.catalogEntryName = o.catalogEntryArg
+		return nil
+	}
+
+	catalogType, err := FindCatalogTypeByName(ctx, logger, cl, typeName)
+	if isNotFoundError(err) {
+		o.catalogEntryName = o.catalogEntryArg
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	o.catalogTypeID, o.catalogEntryName = catalogType.Id, entryName
+	return nil
 }
 
 func (o *GetCatalogEntriesOptions) Run(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) error {
@@ -57,94 +122,135 @@ func (o *GetCatalogEntriesOptions) Run(ctx context.Context, logger kitlog.Logger
 		return fmt.Errorf("--entry-id is mutually exclusive with both --type-id and --type-name")
 	}
 
+	if o.limit < 0 {
+		return fmt.Errorf("--limit must not be negative: %d", o.limit)
+	}
+
+	if o.pageSize < 1 || o.pageSize > defaultPageSize {
+		return fmt.Errorf("--page-size must be between 1 and %d: %d", defaultPageSize, o.pageSize)
+	}
+
+	if o.catalogEntryArg != "" {
+		if err := o.resolveEntryArg(ctx, logger, cl); err != nil {
+			return fmt.Errorf("failed to find catalog entry: %s", err)
+		}
+	}
+
+	w, err := newRecordWriter(o.output)
+	if err != nil {
+		return err
+	}
+
+	record := func(entry client.CatalogEntryV2) (any, error) { return entry, nil }
+	if !o.raw {
+		resolver, err := newCatalogResolver(ctx, logger, cl)
+		if err != nil {
+			return fmt.Errorf("failed to list catalog types: %s", err)
+		}
+		record = func(entry client.CatalogEntryV2) (any, error) {
+			resolved, err := resolver.resolve(ctx, logger, cl, entry)
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve attributes of %s: %s", entry.Name, err)
+			}
+			return resolved, nil
+		}
+	}
+	write := func(entry client.CatalogEntryV2) error {
+		v, err := record(entry)
+		if err != nil {
+			return err
+		}
+		return w.Write(v)
+	}
+
 	if o.catalogEntryID != "" {
 		res, err := FindCatalogEntryByID(ctx, logger, cl, o.catalogEntryID)
 		if err != nil {
 			return fmt.Errorf("failed to find catalog entry: %s", err)
 		}
 
-		if err := serialize(res); err != nil {
-			return fmt.Errorf("failed to marshal json: %q", err)
+		v, err := record(*res)
+		if err != nil {
+			return err
 		}
-
-		return nil
+		return o.printOne(w, v)
 	}
 
-	if o.catalogTypeID != "" {
-		if o.catalogEntryName != "" {
-			res, err := FindCatalogEntryByNameWithTypeID(ctx, logger, cl, o.catalogEntryName, o.catalogTypeID)
-			if err != nil {
-				return fmt.Errorf("failed to find catalog entry: %s", err)
-			}
+	if o.catalogTypeName != "" {
+		catalogType, err := FindCatalogTypeByName(ctx, logger, cl, o.catalogTypeName)
+		if err != nil {
+			return fmt.Errorf("failed to find catalog entry: %s", err)
+		}
+		o.catalogTypeID = catalogType.Id
+	}
 
-			if err := serialize(res); err != nil {
-				return fmt.Errorf("failed to marshal json: %q", err)
-			}
+	if o.catalogTypeID != "" && o.catalogEntryName != "" {
+		res, err := FindCatalogEntryByNameWithTypeID(ctx, logger, cl, o.catalogEntryName, o.catalogTypeID)
+		if err != nil {
+			return fmt.Errorf("failed to find catalog entry: %s", err)
+		}
 
-			return nil
-		} else {
-			res, err := ListAllCatalogEntriesByTypeID(ctx, logger, cl, o.catalogTypeID)
-			if err != nil {
-				return fmt.Errorf("failed to find catalog entry: %s", err)
-			}
+		v, err := record(*res)
+		if err != nil {
+			return err
+		}
+		return o.printOne(w, v)
+	}
 
-			if err := serialize(res); err != nil {
-				return fmt.Errorf("failed to marshal json: %q", err)
-			}
+	if o.catalogTypeID == "" && o.catalogEntryName != "" {
+		res, err := FindCatalogEntriesByName(ctx, logger, cl, o.catalogEntryName)
+		if err != nil {
+			return fmt.Errorf("failed to find catalog entry: %s", err)
+		}
 
-			return nil
+		if o.limit > 0 && len(res) > o.limit {
+			res = res[:o.limit]
 		}
-	} else if o.catalogTypeName != "" {
-		if o.catalogEntryName != "" {
-			res, err := FindCatalogEntryByNameWithTypeName(ctx, logger, cl, o.catalogEntryName, o.catalogTypeName)
-			if err != nil {
-				return fmt.Errorf("failed to find catalog entry: %s", err)
-			}
 
-			if err := serialize(res); err != nil {
-				return fmt.Errorf("failed to marshal json: %q", err)
-			}
-		} else {
-			res, err := ListAllCatalogEntriesByTypeName(ctx, logger, cl, o.catalogTypeName)
-			if err != nil {
-				return fmt.Errorf("failed to find catalog entry: %s", err)
+		for _, entry := range res {
+			if err := write(entry); err != nil {
+				return err
 			}
+		}
 
-			if err := serialize(res); err != nil {
-				return fmt.Errorf("failed to marshal json: %q", err)
-			}
+		return w.Close()
+	}
 
-			return nil
+	count := 0
+	visit := func(entry client.CatalogEntryV2) error {
+		if err := write(entry); err != nil {
+			return err
 		}
-	} else {
-		res, err := ListAllCatalogEntries(ctx, logger, cl)
-		if err != nil {
-			return fmt.Errorf("failed to list all catalog entries: %s", err)
+		if count++; o.limit > 0 && count >= o.limit {
+			return errStopWalk
 		}
+		return nil
+	}
 
-		if o.catalogEntryName != "" {
-			n := 0
-			for _, v := range res {
-				if v.Name == o.catalogEntryName {
-					res[n] = v
-					n++
-				}
-			}
-			res = res[:n]
-		} else if o.catalogEntryID != "" {
-			n := 0
-			for _, v := range res {
-				if v.Id == o.catalogEntryID {
-					res[n] = v
-					n++
-				}
-			}
-			res = res[:n]
-		}
+	if o.catalogTypeID != "" {
+		err = WalkCatalogEntriesByTypeID(ctx, logger, cl, o.catalogTypeID, o.pageSize, visit)
+	} else {
+		err = WalkAllCatalogEntries(ctx, logger, cl, o.pageSize, visit)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to list all catalog entries: %s", err)
+	}
 
-		if err := serialize(res); err != nil {
+	return w.Close()
+}
+
+// printOne prints a single catalog entry as an object, rather than a one element array,
+// unless streaming jsonl.
+func (o *GetCatalogEntriesOptions) printOne(w *recordWriter, entry any) error {
+	if o.output == outputJSON {
+		if err := serialize(entry); err != nil {
 			return fmt.Errorf("failed to marshal json: %q", err)
 		}
+		return nil
 	}
-	return nil
+
+	if err := w.Write(entry); err != nil {
+		return err
+	}
+	return w.Close()
 }
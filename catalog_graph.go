@@ -0,0 +1,361 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/alexeldeib/incli/client"
+	kitlog "github.com/go-kit/log"
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+)
+
+const outputDOT = "dot"
+
+func NewCatalogGraphCommand() *cobra.Command {
+	opts := &CatalogGraphOptions{}
+	cmd := &cobra.Command{
+		Use:   "graph",
+		Short: "show which catalog entries an entry references and which reference it",
+		Long: `show which catalog entries an entry references and which reference it.
+
+Follows every attribute of every catalog type that refers to another catalog type, such
+as a service's owning team or the services it depends on. "references" are the entries
+the given one points at, e.g. its team, and "referenced by" the entries pointing at it,
+e.g. the services depending on it. Both are followed --depth steps, so with the default
+of 2 the dependents of its dependents are shown too. Each entry is expanded once, where
+it is fewest steps away, and marked as seen anywhere else it appears.
+
+The entry is given as for inc catalog entries get: an ID, a name, alias or external ID,
+or TYPE/NAME such as Service/checkout-api. Every catalog entry is fetched to find the
+references, which can take a while for large catalogs.
+
+  inc catalog graph --entry Service/checkout-api -o dot | dot -Tsvg > checkout-api.svg`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, logger, cl, err := setup()
+			if err != nil {
+				fmt.Printf("failed to setup: %s", err)
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger, cl); err != nil {
+				logger.Log("msg", "failed to run", "error", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.entry, "entry", "", "catalog entry to start from, e.g. Service/checkout-api")
+	cmd.Flags().IntVar(&opts.depth, "depth", 2, "how many references to follow in each direction, 0 for no limit")
+	cmd.Flags().StringVar(&opts.direction, "direction", "both", "which references to follow, one of: both, references, referenced-by")
+	cmd.Flags().StringVarP(&opts.output, "output", "o", outputText, "output format, one of: text, dot, json")
+
+	return cmd
+}
+
+type CatalogGraphOptions struct {
+	entry     string
+	depth     int
+	direction string
+	output    string
+}
+
+// catalogEdge is a reference from one catalog entry to another through an attribute.
+type catalogEdge struct {
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Attribute string `json:"attribute"`
+}
+
+// catalogGraph holds every catalog entry and the references between them.
+type catalogGraph struct {
+	types    map[string]client.CatalogTypeV2
+	entries  map[string]client.CatalogEntryV2
+	outgoing map[string][]catalogEdge
+	incoming map[string][]catalogEdge
+}
+
+// catalogGraphNode is an entry reached from the starting one, through Attribute.
+type catalogGraphNode struct {
+	Id          string              `json:"id"`
+	Name        string              `json:"name"`
+	CatalogType string              `json:"catalog_type"`
+	Attribute   string              `json:"attribute,omitempty"`
+	Seen        bool                `json:"seen,omitempty"`
+	Children    []*catalogGraphNode `json:"children,omitempty"`
+}
+
+type catalogGraphResult struct {
+	Entry catalogGraphNode `json:"entry"`
+	// either is nil, null in json, when --direction leaves it out
+	References   []*catalogGraphNode `json:"references"`
+	ReferencedBy []*catalogGraphNode `json:"referenced_by"`
+}
+
+func (o *CatalogGraphOptions) Run(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) error {
+	if err := validateOutput(o.output, outputText, outputDOT, outputJSON); err != nil {
+		return err
+	}
+
+	directions := []string{"both", "references", "referenced-by"}
+	if !lo.Contains(directions, o.direction) {
+		return fmt.Errorf("--direction must be one of %v: %q", directions, o.direction)
+	}
+
+	if o.depth < 0 {
+		return fmt.Errorf("--depth must not be negative: %d", o.depth)
+	}
+
+	if o.entry == "" {
+		return fmt.Errorf("--entry must be given")
+	}
+
+	graph, err := buildCatalogGraph(ctx, logger, cl)
+	if err != nil {
+		return err
+	}
+
+	start, err := graph.find(o.entry)
+	if err != nil {
+		return fmt.Errorf("failed to find catalog entry: %s", err)
+	}
+
+	result := catalogGraphResult{Entry: graph.node(start.Id, "")}
+	if o.direction != "referenced-by" {
+		result.References = graph.walk(start.Id, graph.outgoing, o.depth)
+	}
+	if o.direction != "references" {
+		result.ReferencedBy = graph.walk(start.Id, graph.incoming, o.depth)
+	}
+
+	logger.Log("msg", "walked catalog graph", "entries", len(graph.entries), "references", countGraphNodes(result.References), "referenced_by", countGraphNodes(result.ReferencedBy))
+
+	switch o.output {
+	case outputJSON:
+		if err := serialize(result); err != nil {
+			return fmt.Errorf("failed to marshal json: %q", err)
+		}
+		return nil
+	case outputDOT:
+		_, err = os.Stdout.WriteString(catalogGraphDOT(result))
+	default:
+		_, err = os.Stdout.WriteString(catalogGraphTree(result))
+	}
+	return errors.Wrap(err, "failed to write output")
+}
+
+func buildCatalogGraph(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) (*catalogGraph, error) {
+	resolver, err := newCatalogResolver(ctx, logger, cl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list catalog types: %s", err)
+	}
+
+	entries, err := ListAllCatalogEntries(ctx, logger, cl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list catalog entries: %s", err)
+	}
+
+	graph := &catalogGraph{
+		types:    resolver.types,
+		entries:  lo.KeyBy(entries, func(v client.CatalogEntryV2) string { return v.Id }),
+		outgoing: map[string][]catalogEdge{},
+		incoming: map[string][]catalogEdge{},
+	}
+
+	for _, entry := range entries {
+		for _, attribute := range resolver.attributes(entry) {
+			for _, id := range resolver.references(attribute, entry.AttributeValues[attribute.Id]) {
+				// references to deleted entries lead nowhere
+				if _, ok := graph.entries[id]; !ok {
+					continue
+				}
+				edge := catalogEdge{From: entry.Id, To: id, Attribute: attribute.Name}
+				graph.outgoing[entry.Id] = append(graph.outgoing[entry.Id], edge)
+				graph.incoming[id] = append(graph.incoming[id], edge)
+			}
+		}
+	}
+
+	return graph, nil
+}
+
+// find finds an entry by ID, name, alias or external ID, or by TYPE/NAME. As for inc
+// catalog entries get, when the part before the first slash isn't a catalog type the
+// whole of target is the name.
+func (g *catalogGraph) find(target string) (*client.CatalogEntryV2, error) {
+	typeID, name := "", target
+	if typeName, entryName, ok := strings.Cut(target, "/"); ok {
+		for _, v := range g.types {
+			if strings.EqualFold(v.Name, typeName) || strings.EqualFold(v.TypeName, typeName) {
+				typeID, name = v.Id, entryName
+			}
+		}
+	}
+
+	m := newMatcher("catalog entry", name, describeCatalogEntry)
+	for _, entry := range g.entries {
+		if typeID == "" || entry.CatalogTypeId == typeID {
+			m.addWithID(entry, entry.Id, catalogEntryNames(entry)...)
+		}
+	}
+	return m.one()
+}
+
+func (g *catalogGraph) node(id, attribute string) catalogGraphNode {
+	entry := g.entries[id]
+	return catalogGraphNode{Id: id, Name: entry.Name, CatalogType: g.types[entry.CatalogTypeId].Name, Attribute: attribute}
+}
+
+// walk follows edges away from start breadth first, up to depth steps when depth isn't
+// 0. Every entry is expanded only where it is first reached, the fewest steps from start,
+// and marked as seen wherever else it appears. That also stops at cycles, and keeps
+// densely connected catalogs from listing every path through them.
+func (g *catalogGraph) walk(start string, edges map[string][]catalogEdge, depth int) []*catalogGraphNode {
+	type step struct {
+		id       string
+		level    int
+		children *[]*catalogGraphNode
+	}
+
+	var roots []*catalogGraphNode
+	seen := map[string]bool{start: true}
+	queue := []step{{id: start, level: 1, children: &roots}}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		nodes := []*catalogGraphNode{}
+		for _, edge := range edges[current.id] {
+			node := g.node(lo.Ternary(edge.From == current.id, edge.To, edge.From), edge.Attribute)
+			nodes = append(nodes, &node)
+		}
+		sort.SliceStable(nodes, func(i, j int) bool {
+			if nodes[i].Attribute != nodes[j].Attribute {
+				return nodes[i].Attribute < nodes[j].Attribute
+			}
+			return nodes[i].Name < nodes[j].Name
+		})
+
+		for _, node := range nodes {
+			if seen[node.Id] {
+				node.Seen = true
+				continue
+			}
+			seen[node.Id] = true
+			if depth == 0 || current.level < depth {
+				queue = append(queue, step{id: node.Id, level: current.level + 1, children: &node.Children})
+			}
+		}
+		*current.children = nodes
+	}
+	return roots
+}
+
+func countGraphNodes(nodes []*catalogGraphNode) int {
+	count := len(nodes)
+	for _, node := range nodes {
+		count += countGraphNodes(node.Children)
+	}
+	return count
+}
+
+func (n catalogGraphNode) label() string {
+	return fmt.Sprintf("%s/%s", n.CatalogType, n.Name)
+}
+
+// catalogGraphTree draws the references as an indented tree, e.g.
+//
+//	Service/checkout-api
+//	├── references
+//	│   └── Owner: Team/Payments
+//	└── referenced by
+//	    └── Depends on: Service/frontend
+func catalogGraphTree(result catalogGraphResult) string {
+	var b strings.Builder
+	b.WriteString(result.Entry.label() + "\n")
+
+	var draw func(nodes []*catalogGraphNode, indent string)
+	draw = func(nodes []*catalogGraphNode, indent string) {
+		for i, node := range nodes {
+			branch, next := "├── ", "│   "
+			if i == len(nodes)-1 {
+				branch, next = "└── ", "    "
+			}
+			fmt.Fprintf(&b, "%s%s%s: %s%s\n", indent, branch, node.Attribute, node.label(), lo.Ternary(node.Seen, " (seen)", ""))
+			draw(node.Children, indent+next)
+		}
+	}
+
+	var sections []lo.Tuple2[string, []*catalogGraphNode]
+	if result.References != nil {
+		sections = append(sections, lo.T2("references", result.References))
+	}
+	if result.ReferencedBy != nil {
+		sections = append(sections, lo.T2("referenced by", result.ReferencedBy))
+	}
+	for i, section := range sections {
+		branch, next := "├── ", "│   "
+		if i == len(sections)-1 {
+			branch, next = "└── ", "    "
+		}
+		b.WriteString(branch + section.A + "\n")
+		if len(section.B) == 0 {
+			b.WriteString(next + "(none)\n")
+		}
+		draw(section.B, next)
+	}
+	return b.String()
+}
+
+// catalogGraphDOT writes the references as a Graphviz digraph, each edge pointing from
+// the entry holding the reference to the entry it refers to.
+func catalogGraphDOT(result catalogGraphResult) string {
+	nodes := map[string]catalogGraphNode{result.Entry.Id: result.Entry}
+	edges := map[catalogEdge]bool{}
+
+	var collect func(parent *catalogGraphNode, children []*catalogGraphNode, outgoing bool)
+	collect = func(parent *catalogGraphNode, children []*catalogGraphNode, outgoing bool) {
+		for _, child := range children {
+			if _, ok := nodes[child.Id]; !ok {
+				nodes[child.Id] = *child
+			}
+			edge := catalogEdge{From: child.Id, To: parent.Id, Attribute: child.Attribute}
+			if outgoing {
+				edge.From, edge.To = parent.Id, child.Id
+			}
+			edges[edge] = true
+			collect(child, child.Children, outgoing)
+		}
+	}
+	collect(&result.Entry, result.References, true)
+	collect(&result.Entry, result.ReferencedBy, false)
+
+	quote := func(s string) string {
+		return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+	}
+
+	var b strings.Builder
+	b.WriteString("digraph catalog {\n  rankdir=LR;\n  node [shape=box];\n")
+	ids := lo.Keys(nodes)
+	sort.Strings(ids)
+	for _, id := range ids {
+		node := nodes[id]
+		fmt.Fprintf(&b, "  %s [label=%s%s];\n", quote(id), quote(node.Name+"\n"+node.CatalogType), lo.Ternary(id == result.Entry.Id, ", style=bold", ""))
+	}
+
+	sorted := lo.Keys(edges)
+	sort.Slice(sorted, func(i, j int) bool {
+		a, c := sorted[i], sorted[j]
+		return a.From+a.To+a.Attribute < c.From+c.To+c.Attribute
+	})
+	for _, edge := range sorted {
+		fmt.Fprintf(&b, "  %s -> %s [label=%s];\n", quote(edge.From), quote(edge.To), quote(edge.Attribute))
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
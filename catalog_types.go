@@ -13,9 +13,15 @@ import (
 func NewGetCatalogTypesCommand() *cobra.Command {
 	opts := &GetCatalogTypesOptions{}
 	cmd := &cobra.Command{
-		Use:   "get",
+		Use:   "get [NAME|ID]",
 		Short: "get one or all catalog types, by name or id",
+		Args:  cobra.MaximumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
+			if err := opts.Complete(args); err != nil {
+				fmt.Printf("invalid arguments: %s", err)
+				os.Exit(1)
+			}
+
 			ctx, logger, cl, err := setup()
 			if err != nil {
 				fmt.Printf("failed to setup: %s", err)
@@ -40,6 +46,24 @@ type GetCatalogTypesOptions struct {
 	catalogTypeID   string
 }
 
+func (o *GetCatalogTypesOptions) Complete(args []string) error {
+	if len(args) == 0 {
+		return nil
+	}
+
+	if o.catalogTypeName != "" || o.catalogTypeID != "" {
+		return fmt.Errorf("only one of --id, --name or a positional identifier may be specified")
+	}
+
+	if IsULID(args[0]) {
+		o.catalogTypeID = args[0]
+	} else {
+		o.catalogTypeName = args[0]
+	}
+
+	return nil
+}
+
 func (o *GetCatalogTypesOptions) Run(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) error {
 	if o.catalogTypeName != "" && o.catalogTypeID != "" {
 		return fmt.Errorf("exactly one of --type-name or --type-id may be specified")
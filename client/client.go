@@ -30,10 +30,10 @@ func New(ctx context.Context, apiKey, apiEndpoint, version string, opts ...Clien
 		if err == nil && resp.StatusCode > 299 {
 			data, err := io.ReadAll(resp.Body)
 			if err != nil {
-				return nil, fmt.Errorf("status %d: no response body", resp.StatusCode)
+				return nil, &APIError{StatusCode: resp.StatusCode, Body: "no response body"}
 			}
 
-			return nil, fmt.Errorf("status %d: %s", resp.StatusCode, string(data))
+			return nil, &APIError{StatusCode: resp.StatusCode, Body: string(data)}
 		}
 
 		return resp, err
@@ -57,6 +57,23 @@ func New(ctx context.Context, apiKey, apiEndpoint, version string, opts ...Clien
 	return client, nil
 }
 
+// APIError is returned for any response with a status code above 299, carrying the
+// response body which usually explains what went wrong.
+type APIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("status %d: %s", e.StatusCode, e.Body)
+}
+
+// IsNotFound reports whether err was caused by a 404 response.
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
 // WithReadOnly restricts the client to GET requests only, useful when creating a client
 // for the purpose of dry-running.
 func WithReadOnly() ClientOption {
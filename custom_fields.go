@@ -0,0 +1,614 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/alexeldeib/incli/client"
+	kitlog "github.com/go-kit/log"
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+)
+
+var customFieldTypes = []client.CreateRequestBody3FieldType{
+	client.CreateRequestBody3FieldTypeText,
+	client.CreateRequestBody3FieldTypeLink,
+	client.CreateRequestBody3FieldTypeNumeric,
+	client.CreateRequestBody3FieldTypeSingleSelect,
+	client.CreateRequestBody3FieldTypeMultiSelect,
+}
+
+func NewCustomFieldsCommand() *cobra.Command {
+	root := &cobra.Command{
+		Use:     "custom-fields",
+		Aliases: []string{"custom-field", "cf"},
+		Short:   "manage the custom fields shown on incidents",
+	}
+	options := &cobra.Command{
+		Use:   "options",
+		Short: "manage the options of select custom fields",
+	}
+
+	root.AddCommand(NewGetCustomFieldsCommand())
+	root.AddCommand(NewCreateCustomFieldCommand())
+	root.AddCommand(NewUpdateCustomFieldCommand())
+	root.AddCommand(NewDeleteCustomFieldCommand())
+	root.AddCommand(options)
+	options.AddCommand(NewGetCustomFieldOptionsCommand())
+	options.AddCommand(NewAddCustomFieldOptionCommand())
+	options.AddCommand(NewUpdateCustomFieldOptionCommand())
+	options.AddCommand(NewRemoveCustomFieldOptionCommand())
+
+	return root
+}
+
+func NewGetCustomFieldsCommand() *cobra.Command {
+	opts := &GetCustomFieldsOptions{}
+	cmd := &cobra.Command{
+		Use:   "get [NAME|ID]",
+		Short: "get one or all custom fields, by name or id",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) > 0 {
+				opts.customField = args[0]
+			}
+
+			ctx, logger, cl, err := setup()
+			if err != nil {
+				fmt.Printf("failed to setup: %s", err)
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger, cl); err != nil {
+				logger.Log("msg", "failed to run", "error", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.output, "output", "o", outputJSON, "output format, one of: json, jsonl, table")
+
+	return cmd
+}
+
+type GetCustomFieldsOptions struct {
+	customField string
+	output      string
+}
+
+func (o *GetCustomFieldsOptions) Run(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) error {
+	if err := validateOutput(o.output, outputJSON, outputJSONL, outputTable); err != nil {
+		return err
+	}
+
+	var fields []client.CustomFieldV2
+	if o.customField != "" {
+		field, err := FindCustomField(ctx, logger, cl, o.customField)
+		if err != nil {
+			return fmt.Errorf("failed to find custom field: %s", err)
+		}
+
+		if o.output == outputJSON {
+			if err := serialize(field); err != nil {
+				return fmt.Errorf("failed to marshal json: %q", err)
+			}
+			return nil
+		}
+		fields = []client.CustomFieldV2{*field}
+	} else {
+		res, err := ListAllCustomFields(ctx, logger, cl)
+		if err != nil {
+			return fmt.Errorf("failed to list custom fields: %s", err)
+		}
+		fields = res
+	}
+
+	if o.output == outputTable {
+		return o.printTable(ctx, logger, cl, fields)
+	}
+
+	w, err := newRecordWriter(o.output)
+	if err != nil {
+		return err
+	}
+
+	for _, field := range fields {
+		if err := w.Write(field); err != nil {
+			return err
+		}
+	}
+
+	return w.Close()
+}
+
+// printTable shows each field with the name of the catalog type it is linked to, or
+// the values of its options.
+func (o *GetCustomFieldsOptions) printTable(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, fields []client.CustomFieldV2) error {
+	catalogTypeNames := map[string]string{}
+	if lo.SomeBy(fields, func(field client.CustomFieldV2) bool { return field.CatalogTypeId != nil }) {
+		catalogTypes, err := ListAllCatalogTypes(ctx, logger, cl)
+		if err != nil {
+			return fmt.Errorf("failed to list catalog types: %s", err)
+		}
+		for _, catalogType := range catalogTypes {
+			catalogTypeNames[catalogType.Id] = catalogType.Name
+		}
+	}
+
+	rows := [][]string{}
+	for _, field := range fields {
+		var catalogType, options string
+		switch {
+		case field.CatalogTypeId != nil:
+			catalogType = lo.ValueOr(catalogTypeNames, *field.CatalogTypeId, *field.CatalogTypeId)
+		case field.FieldType == client.SingleSelect || field.FieldType == client.MultiSelect:
+			res, err := ListAllCustomFieldOptions(ctx, logger, cl, field.Id)
+			if err != nil {
+				return fmt.Errorf("failed to list options of custom field %q: %s", field.Name, err)
+			}
+			options = strings.Join(lo.Map(res, func(option client.CustomFieldOptionV1, _ int) string { return option.Value }), ", ")
+		}
+
+		rows = append(rows, []string{field.Id, field.Name, string(field.FieldType), catalogType, options})
+	}
+
+	return writeTable([]string{"id", "name", "type", "catalog type", "options"}, rows)
+}
+
+func NewCreateCustomFieldCommand() *cobra.Command {
+	opts := &CreateCustomFieldOptions{}
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "create a custom field",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, logger, cl, err := setup()
+			if err != nil {
+				fmt.Printf("failed to setup: %s", err)
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger, cl); err != nil {
+				logger.Log("msg", "failed to run", "error", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.name, "name", "", "name of the custom field, e.g. Affected Team")
+	cmd.Flags().StringVar(&opts.description, "description", "", "description shown to responders filling in the field")
+	cmd.Flags().StringVar(&opts.fieldType, "type", "", "field type, one of: text, link, numeric, single_select, multi_select")
+
+	return cmd
+}
+
+type CreateCustomFieldOptions struct {
+	name        string
+	description string
+	fieldType   string
+}
+
+func (o *CreateCustomFieldOptions) Run(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) error {
+	if o.name == "" {
+		return fmt.Errorf("--name is required")
+	}
+
+	fieldType := client.CreateRequestBody3FieldType(o.fieldType)
+	if !lo.Contains(customFieldTypes, fieldType) {
+		return fmt.Errorf("--type must be one of %v: %q", customFieldTypes, o.fieldType)
+	}
+
+	if err := RequireRoles(ctx, logger, cl, client.IdentityV1RolesManageSettings); err != nil {
+		return err
+	}
+
+	res, err := CreateCustomField(ctx, logger, cl, client.CustomFieldsV2CreateJSONRequestBody{
+		Name:        o.name,
+		Description: o.description,
+		FieldType:   fieldType,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create custom field: %s", err)
+	}
+
+	if err := serialize(res); err != nil {
+		return fmt.Errorf("failed to marshal json: %q", err)
+	}
+
+	return nil
+}
+
+func NewUpdateCustomFieldCommand() *cobra.Command {
+	opts := &UpdateCustomFieldOptions{}
+	cmd := &cobra.Command{
+		Use:   "update NAME|ID",
+		Short: "rename a custom field or change its description",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			opts.customField = args[0]
+			opts.descriptionSet = cmd.Flags().Changed("description")
+
+			ctx, logger, cl, err := setup()
+			if err != nil {
+				fmt.Printf("failed to setup: %s", err)
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger, cl); err != nil {
+				logger.Log("msg", "failed to run", "error", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.name, "name", "", "new name of the custom field, unchanged if empty")
+	cmd.Flags().StringVar(&opts.description, "description", "", "new description of the custom field, unchanged if not given")
+
+	return cmd
+}
+
+type UpdateCustomFieldOptions struct {
+	customField    string
+	name           string
+	description    string
+	descriptionSet bool
+}
+
+func (o *UpdateCustomFieldOptions) Run(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) error {
+	if err := RequireRoles(ctx, logger, cl, client.IdentityV1RolesManageSettings); err != nil {
+		return err
+	}
+
+	field, err := FindCustomField(ctx, logger, cl, o.customField)
+	if err != nil {
+		return fmt.Errorf("failed to find custom field: %s", err)
+	}
+
+	// the API replaces both, so carry over whatever isn't being changed
+	body := client.CustomFieldsV2UpdateJSONRequestBody{
+		Name:        lo.Ternary(o.name != "", o.name, field.Name),
+		Description: lo.Ternary(o.descriptionSet, o.description, field.Description),
+	}
+
+	res, err := UpdateCustomField(ctx, logger, cl, field.Id, body)
+	if err != nil {
+		return fmt.Errorf("failed to update custom field: %s", err)
+	}
+
+	if err := serialize(res); err != nil {
+		return fmt.Errorf("failed to marshal json: %q", err)
+	}
+
+	return nil
+}
+
+func NewDeleteCustomFieldCommand() *cobra.Command {
+	opts := &DeleteCustomFieldOptions{}
+	cmd := &cobra.Command{
+		Use:   "delete NAME|ID",
+		Short: "delete a custom field, along with its value on every incident",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			opts.customField = args[0]
+
+			ctx, logger, cl, err := setup()
+			if err != nil {
+				fmt.Printf("failed to setup: %s", err)
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger, cl); err != nil {
+				logger.Log("msg", "failed to run", "error", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.yes, "yes", false, "confirm the deletion")
+
+	return cmd
+}
+
+type DeleteCustomFieldOptions struct {
+	customField string
+	yes         bool
+}
+
+func (o *DeleteCustomFieldOptions) Run(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) error {
+	if err := RequireRoles(ctx, logger, cl, client.IdentityV1RolesManageSettings); err != nil {
+		return err
+	}
+
+	field, err := FindCustomField(ctx, logger, cl, o.customField)
+	if err != nil {
+		return fmt.Errorf("failed to find custom field: %s", err)
+	}
+
+	if !o.yes {
+		return fmt.Errorf("deleting custom field %q removes its value from every incident, pass --yes to confirm", field.Name)
+	}
+
+	if err := DeleteCustomField(ctx, logger, cl, field.Id); err != nil {
+		return fmt.Errorf("failed to delete custom field: %s", err)
+	}
+
+	logger.Log("msg", "deleted custom field", "name", field.Name, "id", field.Id)
+	return nil
+}
+
+// findSelectCustomField finds a custom field whose options are managed directly, rather
+// than through a linked catalog type.
+func findSelectCustomField(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, target string) (*client.CustomFieldV2, error) {
+	field, err := FindCustomField(ctx, logger, cl, target)
+	if err != nil {
+		return nil, err
+	}
+
+	if field.CatalogTypeId != nil {
+		return nil, fmt.Errorf("the options of custom field %q come from a catalog type, manage them with inc catalog", field.Name)
+	}
+
+	if field.FieldType != client.SingleSelect && field.FieldType != client.MultiSelect {
+		return nil, fmt.Errorf("custom field %q is a %s field, only select fields have options", field.Name, field.FieldType)
+	}
+
+	return field, nil
+}
+
+func NewGetCustomFieldOptionsCommand() *cobra.Command {
+	opts := &GetCustomFieldOptionsOptions{}
+	cmd := &cobra.Command{
+		Use:   "get FIELD",
+		Short: "list the options of a select custom field",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			opts.customField = args[0]
+
+			ctx, logger, cl, err := setup()
+			if err != nil {
+				fmt.Printf("failed to setup: %s", err)
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger, cl); err != nil {
+				logger.Log("msg", "failed to run", "error", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.output, "output", "o", outputJSON, "output format, one of: json, jsonl, table")
+
+	return cmd
+}
+
+type GetCustomFieldOptionsOptions struct {
+	customField string
+	output      string
+}
+
+func (o *GetCustomFieldOptionsOptions) Run(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) error {
+	if err := validateOutput(o.output, outputJSON, outputJSONL, outputTable); err != nil {
+		return err
+	}
+
+	field, err := findSelectCustomField(ctx, logger, cl, o.customField)
+	if err != nil {
+		return fmt.Errorf("failed to find custom field: %s", err)
+	}
+
+	options, err := ListAllCustomFieldOptions(ctx, logger, cl, field.Id)
+	if err != nil {
+		return fmt.Errorf("failed to list custom field options: %s", err)
+	}
+
+	if o.output == outputTable {
+		rows := lo.Map(options, func(option client.CustomFieldOptionV1, _ int) []string {
+			return []string{option.Id, option.Value, strconv.FormatInt(option.SortKey, 10)}
+		})
+		return writeTable([]string{"id", "value", "sort key"}, rows)
+	}
+
+	w, err := newRecordWriter(o.output)
+	if err != nil {
+		return err
+	}
+
+	for _, option := range options {
+		if err := w.Write(option); err != nil {
+			return err
+		}
+	}
+
+	return w.Close()
+}
+
+func NewAddCustomFieldOptionCommand() *cobra.Command {
+	opts := &AddCustomFieldOptionOptions{}
+	cmd := &cobra.Command{
+		Use:   "add FIELD VALUE",
+		Short: "add an option to a select custom field",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			opts.customField, opts.value = args[0], args[1]
+			opts.sortKeySet = cmd.Flags().Changed("sort-key")
+
+			ctx, logger, cl, err := setup()
+			if err != nil {
+				fmt.Printf("failed to setup: %s", err)
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger, cl); err != nil {
+				logger.Log("msg", "failed to run", "error", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().Int64Var(&opts.sortKey, "sort-key", 0, "position of the option, lower first. Defaults to after the existing options")
+
+	return cmd
+}
+
+type AddCustomFieldOptionOptions struct {
+	customField string
+	value       string
+	sortKey     int64
+	sortKeySet  bool
+}
+
+func (o *AddCustomFieldOptionOptions) Run(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) error {
+	if err := RequireRoles(ctx, logger, cl, client.IdentityV1RolesManageSettings); err != nil {
+		return err
+	}
+
+	field, err := findSelectCustomField(ctx, logger, cl, o.customField)
+	if err != nil {
+		return fmt.Errorf("failed to find custom field: %s", err)
+	}
+
+	body := client.CustomFieldOptionsV1CreateJSONRequestBody{
+		CustomFieldId: field.Id,
+		Value:         o.value,
+	}
+	if o.sortKeySet {
+		body.SortKey = &o.sortKey
+	}
+
+	res, err := CreateCustomFieldOption(ctx, logger, cl, body)
+	if err != nil {
+		return fmt.Errorf("failed to add custom field option: %s", err)
+	}
+
+	if err := serialize(res); err != nil {
+		return fmt.Errorf("failed to marshal json: %q", err)
+	}
+
+	return nil
+}
+
+func NewUpdateCustomFieldOptionCommand() *cobra.Command {
+	opts := &UpdateCustomFieldOptionOptions{}
+	cmd := &cobra.Command{
+		Use:   "update FIELD OPTION",
+		Short: "rename or reorder an option of a select custom field, by value or id",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			opts.customField, opts.option = args[0], args[1]
+			opts.sortKeySet = cmd.Flags().Changed("sort-key")
+
+			ctx, logger, cl, err := setup()
+			if err != nil {
+				fmt.Printf("failed to setup: %s", err)
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger, cl); err != nil {
+				logger.Log("msg", "failed to run", "error", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.value, "value", "", "new value of the option, unchanged if empty")
+	cmd.Flags().Int64Var(&opts.sortKey, "sort-key", 0, "new position of the option, unchanged if not given")
+
+	return cmd
+}
+
+type UpdateCustomFieldOptionOptions struct {
+	customField string
+	option      string
+	value       string
+	sortKey     int64
+	sortKeySet  bool
+}
+
+func (o *UpdateCustomFieldOptionOptions) Run(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) error {
+	if err := RequireRoles(ctx, logger, cl, client.IdentityV1RolesManageSettings); err != nil {
+		return err
+	}
+
+	field, err := findSelectCustomField(ctx, logger, cl, o.customField)
+	if err != nil {
+		return fmt.Errorf("failed to find custom field: %s", err)
+	}
+
+	option, err := FindCustomFieldOption(ctx, logger, cl, field.Id, o.option)
+	if err != nil {
+		return fmt.Errorf("failed to find custom field option: %s", err)
+	}
+
+	body := client.CustomFieldOptionsV1UpdateJSONRequestBody{
+		Value:   lo.Ternary(o.value != "", o.value, option.Value),
+		SortKey: lo.Ternary(o.sortKeySet, o.sortKey, option.SortKey),
+	}
+
+	res, err := UpdateCustomFieldOption(ctx, logger, cl, option.Id, body)
+	if err != nil {
+		return fmt.Errorf("failed to update custom field option: %s", err)
+	}
+
+	if err := serialize(res); err != nil {
+		return fmt.Errorf("failed to marshal json: %q", err)
+	}
+
+	return nil
+}
+
+func NewRemoveCustomFieldOptionCommand() *cobra.Command {
+	opts := &RemoveCustomFieldOptionOptions{}
+	cmd := &cobra.Command{
+		Use:   "remove FIELD OPTION",
+		Short: "remove an option from a select custom field, by value or id",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			opts.customField, opts.option = args[0], args[1]
+
+			ctx, logger, cl, err := setup()
+			if err != nil {
+				fmt.Printf("failed to setup: %s", err)
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger, cl); err != nil {
+				logger.Log("msg", "failed to run", "error", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	return cmd
+}
+
+type RemoveCustomFieldOptionOptions struct {
+	customField string
+	option      string
+}
+
+func (o *RemoveCustomFieldOptionOptions) Run(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) error {
+	if err := RequireRoles(ctx, logger, cl, client.IdentityV1RolesManageSettings); err != nil {
+		return err
+	}
+
+	field, err := findSelectCustomField(ctx, logger, cl, o.customField)
+	if err != nil {
+		return fmt.Errorf("failed to find custom field: %s", err)
+	}
+
+	option, err := FindCustomFieldOption(ctx, logger, cl, field.Id, o.option)
+	if err != nil {
+		return fmt.Errorf("failed to find custom field option: %s", err)
+	}
+
+	if err := DeleteCustomFieldOption(ctx, logger, cl, option.Id); err != nil {
+		return fmt.Errorf("failed to remove custom field option: %s", err)
+	}
+
+	logger.Log("msg", "removed custom field option", "field", field.Name, "value", option.Value, "id", option.Id)
+	return nil
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/alexeldeib/incli/client"
+	kitlog "github.com/go-kit/log"
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+)
+
+var followUpStatuses = []client.FollowUpV2Status{client.Outstanding, client.Completed, client.NotDoing, client.Deleted}
+
+var incidentModes = []string{"standard", "retrospective", "test", "tutorial"}
+
+func NewFollowUpsCommand() *cobra.Command {
+	root := &cobra.Command{
+		Use:     "follow-ups",
+		Aliases: []string{"follow-up", "followups"},
+		Short:   "inspect incident follow-ups",
+	}
+
+	root.AddCommand(NewGetFollowUpsCommand())
+
+	return root
+}
+
+func NewGetFollowUpsCommand() *cobra.Command {
+	opts := &GetFollowUpsOptions{}
+	cmd := &cobra.Command{
+		Use:   "get",
+		Short: "get the follow-ups of one incident, or across every incident",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, logger, cl, err := setup()
+			if err != nil {
+				fmt.Printf("failed to setup: %s", err)
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger, cl); err != nil {
+				logger.Log("msg", "failed to run", "error", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.incident, "incident", "", "only follow-ups of this incident, e.g. INC-123 or an incident id")
+	cmd.Flags().StringVar(&opts.status, "status", "", "only follow-ups with this status, one of: outstanding, completed, not_doing, deleted")
+	cmd.Flags().StringVar(&opts.mode, "mode", "", "only follow-ups of incidents in this mode, one of: standard, retrospective, test, tutorial. Defaults to standard and retrospective")
+	cmd.Flags().StringVar(&opts.assignee, "assignee", "", "only follow-ups assigned to this user, by email, Slack user ID, name or ID")
+	cmd.Flags().StringVarP(&opts.output, "output", "o", outputJSON, "output format, one of: json, jsonl, table")
+
+	return cmd
+}
+
+type GetFollowUpsOptions struct {
+	incident string
+	status   string
+	mode     string
+	assignee string
+	output   string
+}
+
+func (o *GetFollowUpsOptions) Run(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) error {
+	if err := validateOutput(o.output, outputJSON, outputJSONL, outputTable); err != nil {
+		return err
+	}
+
+	if o.status != "" && !lo.Contains(followUpStatuses, client.FollowUpV2Status(o.status)) {
+		return fmt.Errorf("--status must be one of %v: %q", followUpStatuses, o.status)
+	}
+
+	if o.mode != "" && !lo.Contains(incidentModes, o.mode) {
+		return fmt.Errorf("--mode must be one of %v: %q", incidentModes, o.mode)
+	}
+
+	params := client.FollowUpsV2ListParams{}
+	if o.incident != "" {
+		id, err := FindIncidentID(ctx, logger, cl, o.incident)
+		if err != nil {
+			return fmt.Errorf("failed to find incident: %s", err)
+		}
+		params.IncidentId = &id
+	}
+	if o.mode != "" {
+		params.IncidentMode = lo.ToPtr(client.FollowUpsV2ListParamsIncidentMode(o.mode))
+	}
+
+	var assigneeID string
+	if o.assignee != "" {
+		user, err := FindUser(ctx, logger, cl, o.assignee)
+		if err != nil {
+			return fmt.Errorf("failed to find assignee: %s", err)
+		}
+		assigneeID = user.Id
+	}
+
+	res, err := ListAllFollowUps(ctx, logger, cl, params)
+	if err != nil {
+		return fmt.Errorf("failed to list follow-ups: %s", err)
+	}
+
+	followUps := lo.Filter(res, func(followUp client.FollowUpV2, _ int) bool {
+		if o.status != "" && string(followUp.Status) != o.status {
+			return false
+		}
+		return assigneeID == "" || (followUp.Assignee != nil && followUp.Assignee.Id == assigneeID)
+	})
+
+	if o.output == outputTable {
+		references, err := IncidentReferencesByID(ctx, logger, cl, lo.Map(followUps, func(followUp client.FollowUpV2, _ int) string { return followUp.IncidentId }))
+		if err != nil {
+			return fmt.Errorf("failed to find incident references: %s", err)
+		}
+
+		rows := lo.Map(followUps, func(followUp client.FollowUpV2, _ int) []string {
+			var priority, issue string
+			if followUp.Priority != nil {
+				priority = followUp.Priority.Name
+			}
+			if followUp.ExternalIssueReference != nil {
+				issue = followUp.ExternalIssueReference.IssuePermalink
+			}
+			return []string{followUp.Id, references[followUp.IncidentId], string(followUp.Status), priority, userLabel(followUp.Assignee), followUp.Title, issue}
+		})
+		return writeTable([]string{"id", "incident", "status", "priority", "assignee", "title", "issue"}, rows)
+	}
+
+	w, err := newRecordWriter(o.output)
+	if err != nil {
+		return err
+	}
+
+	for _, followUp := range followUps {
+		if err := w.Write(followUp); err != nil {
+			return err
+		}
+	}
+
+	return w.Close()
+}
+
+// userLabel is how a user is shown in tables, their email address when known.
+func userLabel(user *client.UserV1) string {
+	if user == nil {
+		return ""
+	}
+	if user.Email != nil && *user.Email != "" {
+		return *user.Email
+	}
+	return user.Name
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+)
+
+// ParseIncidentReference accepts the ways people refer to an incident, INC-123, inc-123,
+// 123 or a permalink such as https://app.incident.io/acme/incidents/123, and returns the
+// reference number.
+func ParseIncidentReference(input string) (int, error) {
+	value := strings.TrimSpace(input)
+
+	if strings.HasPrefix(value, "http://") || strings.HasPrefix(value, "https://") {
+		u, err := url.Parse(value)
+		if err != nil {
+			return 0, fmt.Errorf("invalid incident permalink %q: %s", input, err)
+		}
+		if host := u.Hostname(); host != "incident.io" && !strings.HasSuffix(host, ".incident.io") {
+			return 0, fmt.Errorf("invalid incident permalink %q: expected an incident.io link", input)
+		}
+
+		segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+		if len(segments) < 2 || segments[len(segments)-2] != "incidents" {
+			return 0, fmt.Errorf("invalid incident permalink %q: expected a path ending in /incidents/<reference>", input)
+		}
+		value = segments[len(segments)-1]
+	}
+
+	if len(value) > 4 && strings.EqualFold(value[:4], "INC-") {
+		value = value[4:]
+	}
+
+	// Atoi alone would also accept a sign, e.g. +5
+	isDigits := value != "" && strings.Trim(value, "0123456789") == ""
+	reference, err := strconv.Atoi(value)
+	if !isDigits || err != nil || reference <= 0 {
+		return 0, fmt.Errorf("invalid incident reference %q, expected e.g. INC-123, 123 or a permalink", input)
+	}
+
+	return reference, nil
+}
+
+// IsULID reports whether value looks like an incident.io ID, which are all ULIDs: 26
+// characters of Crockford's base32, e.g. 01FCNDV6P870EA6S7TK1DSYDG0.
+func IsULID(value string) bool {
+	if len(value) != 26 {
+		return false
+	}
+
+	for _, r := range strings.ToUpper(value) {
+		if !strings.ContainsRune("0123456789ABCDEFGHJKMNPQRSTVWXYZ", r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ParseIncidentIdentifier auto-detects whether value is an incident ID or reference,
+// returning exactly one of them.
+func ParseIncidentIdentifier(value string) (id string, reference int, err error) {
+	if IsULID(value) {
+		return strings.ToUpper(value), 0, nil
+	}
+
+	reference, err = ParseIncidentReference(value)
+	if err != nil {
+		return "", 0, fmt.Errorf("%q is neither an incident ID nor a reference: %s", value, err)
+	}
+
+	return "", reference, nil
+}
+
+// notFoundError is returned by the Find* helpers when nothing matched the target,
+// suggesting similarly named candidates.
+type notFoundError struct {
+	kind        string
+	target      string
+	suggestions []string
+}
+
+func newNotFoundError(kind, target string, candidates []string) error {
+	return &notFoundError{kind: kind, target: target, suggestions: suggest(target, candidates)}
+}
+
+func (e *notFoundError) Error() string {
+	msg := fmt.Sprintf("%s %q not found", e.kind, e.target)
+	if len(e.suggestions) == 0 {
+		return msg
+	}
+
+	quoted := make([]string, 0, len(e.suggestions))
+	for _, s := range e.suggestions {
+		quoted = append(quoted, strconv.Quote(s))
+	}
+
+	return fmt.Sprintf("%s, did you mean %s?", msg, strings.Join(quoted, " or "))
+}
+
+// isNotFoundError reports whether err was caused by a Find* helper finding no match.
+func isNotFoundError(err error) bool {
+	var target *notFoundError
+	return errors.As(err, &target)
+}
+
+// suggest returns up to three candidates that are close to target, ignoring case,
+// closest first.
+func suggest(target string, candidates []string) []string {
+	type scored struct {
+		name     string
+		distance int
+	}
+
+	lowerTarget := strings.ToLower(target)
+	maxDistance := max(2, len(lowerTarget)/3)
+
+	var (
+		matches []scored
+		seen    = map[string]bool{}
+	)
+	for _, candidate := range candidates {
+		if seen[candidate] || candidate == "" {
+			continue
+		}
+		seen[candidate] = true
+
+		lowerCandidate := strings.ToLower(candidate)
+		distance := levenshtein(lowerTarget, lowerCandidate)
+		if len(lowerTarget) >= 3 && strings.Contains(lowerCandidate, lowerTarget) {
+			distance = min(distance, 1)
+		}
+
+		if distance <= maxDistance {
+			matches = append(matches, scored{name: candidate, distance: distance})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].distance != matches[j].distance {
+			return matches[i].distance < matches[j].distance
+		}
+		return matches[i].name < matches[j].name
+	})
+
+	var results []string
+	for i := 0; i < len(matches) && i < 3; i++ {
+		results = append(results, matches[i].name)
+	}
+
+	return results
+}
+
+// levenshtein is the number of single rune insertions, deletions and substitutions
+// needed to turn a into b.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(rb)]
+}
+
+// resolveIncidentArgs combines the --id and --ref flags with an optional positional
+// identifier, returning the incident ID or reference number to use.
+func resolveIncidentArgs(args []string, idFlag, refFlag string) (string, int, error) {
+	if len(args) > 0 {
+		if idFlag != "" || refFlag != "" {
+			return "", 0, fmt.Errorf("only one of --id, --ref or a positional identifier may be specified")
+		}
+		return ParseIncidentIdentifier(args[0])
+	}
+
+	if refFlag != "" {
+		reference, err := ParseIncidentReference(refFlag)
+		if err != nil {
+			return "", 0, err
+		}
+		return idFlag, reference, nil
+	}
+
+	return idFlag, 0, nil
+}
+
+// ambiguousError is returned by the Find* helpers when more than one candidate matched
+// the target equally well.
+type ambiguousError struct {
+	kind       string
+	target     string
+	candidates []string
+}
+
+func (e *ambiguousError) Error() string {
+	return fmt.Sprintf("%s %q is ambiguous, it matches %s", e.kind, e.target, strings.Join(e.candidates, ", "))
+}
+
+// matcher implements the matching rules shared by the catalog finders: a candidate
+// matches when any of its names equals the target ignoring case. If several do, one
+// whose name matches exactly, case included, is preferred; otherwise the match is
+// ambiguous.
+type matcher[T any] struct {
+	kind     string
+	target   string
+	describe func(T) string
+
+	exact  []T
+	folded []T
+	names  []string
+}
+
+func newMatcher[T any](kind, target string, describe func(T) string) *matcher[T] {
+	return &matcher[T]{kind: kind, target: target, describe: describe}
+}
+
+// add considers candidate, which can be referred to by any of names.
+func (m *matcher[T]) add(candidate T, names ...string) {
+	m.names = append(m.names, names...)
+
+	switch {
+	case lo.Contains(names, m.target):
+		m.exact = append(m.exact, candidate)
+	case lo.ContainsBy(names, func(name string) bool { return strings.EqualFold(name, m.target) }):
+		m.folded = append(m.folded, candidate)
+	}
+}
+
+// addWithID considers candidate like add, and also matches it by id, which is never
+// suggested.
+func (m *matcher[T]) addWithID(candidate T, id string, names ...string) {
+	if id == m.target {
+		m.names = append(m.names, names...)
+		m.exact = append(m.exact, candidate)
+		return
+	}
+	m.add(candidate, names...)
+}
+
+// one returns the single candidate referred to by the target.
+func (m *matcher[T]) one() (*T, error) {
+	matches := m.exact
+	if len(matches) == 0 {
+		matches = m.folded
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, newNotFoundError(m.kind, m.target, m.names)
+	case 1:
+		return &matches[0], nil
+	}
+
+	return nil, &ambiguousError{
+		kind:   m.kind,
+		target: m.target,
+		candidates: lo.Map(matches, func(candidate T, _ int) string {
+			return m.describe(candidate)
+		}),
+	}
+}
+
+// all returns every candidate referred to by the target, exact matches first.
+func (m *matcher[T]) all() ([]T, error) {
+	matches := append(append([]T{}, m.exact...), m.folded...)
+	if len(matches) == 0 {
+		return nil, newNotFoundError(m.kind, m.target, m.names)
+	}
+	return matches, nil
+}
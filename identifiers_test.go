@@ -0,0 +1,41 @@
+package main
+
+import "testing"
+
+func TestParseIncidentReference(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    int
+		wantErr bool
+	}{
+		{input: "INC-123", want: 123},
+		{input: "inc-123", want: 123},
+		{input: "123", want: 123},
+		{input: " INC-7 ", want: 7},
+		{input: "https://app.incident.io/acme/incidents/123", want: 123},
+		{input: "https://app.incident.io/acme/incidents/123/", want: 123},
+		{input: "https://app.incident.io/acme/incidents/INC-123", want: 123},
+		{input: "https://app.incident.io/acme/follow-ups/123", wantErr: true},
+		{input: "INC-", wantErr: true},
+		{input: "INC-0", wantErr: true},
+		{input: "-5", wantErr: true},
+		{input: "+5", wantErr: true},
+		{input: "INC-+5", wantErr: true},
+		{input: "https://example.com/acme/incidents/5", wantErr: true},
+		{input: "https://app.incident.io.example.com/acme/incidents/5", wantErr: true},
+		{input: "INC-12a", wantErr: true},
+		{input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseIncidentReference(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseIncidentReference(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseIncidentReference(%q) = %d, want %d", tt.input, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/alexeldeib/incli/client"
+	kitlog "github.com/go-kit/log"
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+)
+
+func NewIncidentTypesCommand() *cobra.Command {
+	root := &cobra.Command{
+		Use:     "incident-types",
+		Aliases: []string{"incident-type"},
+		Short:   "inspect incident types",
+		Long: `inspect incident types.
+
+The API only allows reading incident types, manage them in the web UI.`,
+	}
+
+	root.AddCommand(NewGetIncidentTypesCommand())
+
+	return root
+}
+
+func NewGetIncidentTypesCommand() *cobra.Command {
+	opts := &GetIncidentTypesOptions{}
+	cmd := &cobra.Command{
+		Use:   "get [NAME|ID]",
+		Short: "get one or all incident types",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) > 0 {
+				opts.incidentType = args[0]
+			}
+
+			ctx, logger, cl, err := setup()
+			if err != nil {
+				fmt.Printf("failed to setup: %s", err)
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger, cl); err != nil {
+				logger.Log("msg", "failed to run", "error", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.output, "output", "o", outputJSON, "output format, one of: json, jsonl, table")
+
+	return cmd
+}
+
+type GetIncidentTypesOptions struct {
+	incidentType string
+	output       string
+}
+
+func (o *GetIncidentTypesOptions) Run(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) error {
+	if err := validateOutput(o.output, outputJSON, outputJSONL, outputTable); err != nil {
+		return err
+	}
+
+	var incidentTypes []client.IncidentTypeV1
+	if o.incidentType != "" {
+		incidentType, err := FindIncidentType(ctx, logger, cl, o.incidentType)
+		if err != nil {
+			return fmt.Errorf("failed to find incident type: %s", err)
+		}
+
+		if o.output == outputJSON {
+			if err := serialize(incidentType); err != nil {
+				return fmt.Errorf("failed to marshal json: %q", err)
+			}
+			return nil
+		}
+		incidentTypes = []client.IncidentTypeV1{*incidentType}
+	} else {
+		res, err := ListAllIncidentTypes(ctx, logger, cl)
+		if err != nil {
+			return fmt.Errorf("failed to list incident types: %s", err)
+		}
+		incidentTypes = res
+	}
+
+	if o.output == outputTable {
+		rows := lo.Map(incidentTypes, func(v client.IncidentTypeV1, _ int) []string {
+			return []string{v.Id, v.Name, strconv.FormatBool(v.IsDefault), string(v.CreateInTriage), strconv.FormatBool(v.PrivateIncidentsOnly), v.Description}
+		})
+		return writeTable([]string{"id", "name", "default", "create in triage", "private only", "description"}, rows)
+	}
+
+	w, err := newRecordWriter(o.output)
+	if err != nil {
+		return err
+	}
+
+	for _, incidentType := range incidentTypes {
+		if err := w.Write(incidentType); err != nil {
+			return err
+		}
+	}
+
+	return w.Close()
+}
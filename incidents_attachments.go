@@ -0,0 +1,317 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+
+	"github.com/alexeldeib/incli/client"
+	kitlog "github.com/go-kit/log"
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+)
+
+// attachmentResourceTypes are the kinds of external resources that can be attached to
+// an incident. Scrubbed attachments only exist once their resource has been redacted.
+var attachmentResourceTypes = []client.CreateRequestBody4ResourceResourceType{
+	client.CreateRequestBody4ResourceResourceTypeGithubPullRequest,
+	client.CreateRequestBody4ResourceResourceTypePagerDutyIncident,
+	client.CreateRequestBody4ResourceResourceTypeOpsgenieAlert,
+	client.CreateRequestBody4ResourceResourceTypeDatadogMonitorAlert,
+	client.CreateRequestBody4ResourceResourceTypeSentryIssue,
+	client.CreateRequestBody4ResourceResourceTypeStatuspageIncident,
+	client.CreateRequestBody4ResourceResourceTypeAtlassianStatuspageIncident,
+	client.CreateRequestBody4ResourceResourceTypeZendeskTicket,
+	client.CreateRequestBody4ResourceResourceTypeGoogleCalendarEvent,
+}
+
+// attachmentURLs recognise the web URLs of resources that can be attached, capturing
+// their external ID. GitHub pull requests have no ID outside their repository, so the
+// URL itself is their external ID.
+var attachmentURLs = []struct {
+	resourceType client.CreateRequestBody4ResourceResourceType
+	pattern      *regexp.Regexp
+}{
+	{client.CreateRequestBody4ResourceResourceTypeGithubPullRequest, regexp.MustCompile(`^(https://github\.com/[^/]+/[^/]+/pull/\d+)`)},
+	{client.CreateRequestBody4ResourceResourceTypePagerDutyIncident, regexp.MustCompile(`^https://[^/]+\.pagerduty\.com/incidents/([A-Z0-9]+)`)},
+	{client.CreateRequestBody4ResourceResourceTypeOpsgenieAlert, regexp.MustCompile(`^https://[^/]+\.opsgenie\.com/alert/detail/([0-9a-f-]+)`)},
+	{client.CreateRequestBody4ResourceResourceTypeSentryIssue, regexp.MustCompile(`^https://[^/]*sentry\.io/(?:organizations/[^/]+/)?issues/(\d+)`)},
+	{client.CreateRequestBody4ResourceResourceTypeAtlassianStatuspageIncident, regexp.MustCompile(`^https://manage\.statuspage\.io/pages/[^/]+/incidents/([a-z0-9]+)`)},
+	{client.CreateRequestBody4ResourceResourceTypeZendeskTicket, regexp.MustCompile(`^https://[^/]+\.zendesk\.com/agent/tickets/(\d+)`)},
+}
+
+func NewIncidentAttachmentsCommand() *cobra.Command {
+	root := &cobra.Command{
+		Use:     "attachments",
+		Aliases: []string{"attachment"},
+		Short:   "manage the external resources attached to an incident",
+	}
+
+	root.AddCommand(NewGetIncidentAttachmentsCommand())
+	root.AddCommand(NewAddIncidentAttachmentCommand())
+	root.AddCommand(NewRemoveIncidentAttachmentCommand())
+
+	return root
+}
+
+func NewGetIncidentAttachmentsCommand() *cobra.Command {
+	opts := &GetIncidentAttachmentsOptions{}
+	cmd := &cobra.Command{
+		Use:   "get INC-123|ID",
+		Short: "get the attachments of an incident",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			opts.incident = args[0]
+
+			ctx, logger, cl, err := setup()
+			if err != nil {
+				fmt.Printf("failed to setup: %s", err)
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger, cl); err != nil {
+				logger.Log("msg", "failed to run", "error", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.resourceType, "resource-type", "", "only attachments of this kind of resource, e.g. github_pull_request")
+	cmd.Flags().StringVar(&opts.externalID, "external-id", "", "only attachments of the resource with this ID in its own system")
+	cmd.Flags().StringVarP(&opts.output, "output", "o", outputJSON, "output format, one of: json, jsonl, table")
+
+	return cmd
+}
+
+type GetIncidentAttachmentsOptions struct {
+	incident     string
+	resourceType string
+	externalID   string
+	output       string
+}
+
+func (o *GetIncidentAttachmentsOptions) Run(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) error {
+	if err := validateOutput(o.output, outputJSON, outputJSONL, outputTable); err != nil {
+		return err
+	}
+
+	if o.resourceType != "" && !lo.Contains(attachmentResourceTypes, client.CreateRequestBody4ResourceResourceType(o.resourceType)) {
+		return fmt.Errorf("--resource-type must be one of %v: %q", attachmentResourceTypes, o.resourceType)
+	}
+
+	id, err := FindIncidentID(ctx, logger, cl, o.incident)
+	if err != nil {
+		return fmt.Errorf("failed to find incident: %s", err)
+	}
+
+	params := client.IncidentAttachmentsV1ListParams{IncidentId: &id}
+	if o.resourceType != "" {
+		params.ResourceType = lo.ToPtr(client.IncidentAttachmentsV1ListParamsResourceType(o.resourceType))
+	}
+	if o.externalID != "" {
+		params.ExternalId = &o.externalID
+	}
+
+	attachments, err := ListIncidentAttachments(ctx, logger, cl, params)
+	if err != nil {
+		return fmt.Errorf("failed to list incident attachments: %s", err)
+	}
+
+	if o.output == outputTable {
+		rows := lo.Map(attachments, func(v client.IncidentAttachmentV1, _ int) []string {
+			return []string{v.Id, string(v.Resource.ResourceType), v.Resource.ExternalId, v.Resource.Title, v.Resource.Permalink}
+		})
+		return writeTable([]string{"id", "resource type", "external id", "title", "permalink"}, rows)
+	}
+
+	w, err := newRecordWriter(o.output)
+	if err != nil {
+		return err
+	}
+
+	for _, attachment := range attachments {
+		if err := w.Write(attachment); err != nil {
+			return err
+		}
+	}
+
+	return w.Close()
+}
+
+func NewAddIncidentAttachmentCommand() *cobra.Command {
+	opts := &AddIncidentAttachmentOptions{}
+	cmd := &cobra.Command{
+		Use:   "add INC-123|ID",
+		Short: "attach an external resource to an incident",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			opts.incident = args[0]
+
+			ctx, logger, cl, err := setup()
+			if err != nil {
+				fmt.Printf("failed to setup: %s", err)
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger, cl); err != nil {
+				logger.Log("msg", "failed to run", "error", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.resourceType, "resource-type", "", "kind of resource, one of: github_pull_request, pager_duty_incident, opsgenie_alert, datadog_monitor_alert, sentry_issue, statuspage_incident, atlassian_statuspage_incident, zendesk_ticket, google_calendar_event")
+	cmd.Flags().StringVar(&opts.externalID, "external-id", "", "ID of the resource in its own system, e.g. the PagerDuty incident ID")
+
+	return cmd
+}
+
+type AddIncidentAttachmentOptions struct {
+	incident     string
+	resourceType string
+	externalID   string
+}
+
+func (o *AddIncidentAttachmentOptions) Run(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) error {
+	resourceType := client.CreateRequestBody4ResourceResourceType(o.resourceType)
+	if !lo.Contains(attachmentResourceTypes, resourceType) {
+		return fmt.Errorf("--resource-type must be one of %v: %q", attachmentResourceTypes, o.resourceType)
+	}
+
+	if o.externalID == "" {
+		return fmt.Errorf("--external-id is required")
+	}
+
+	return attachResource(ctx, logger, cl, o.incident, resourceType, o.externalID)
+}
+
+func NewAttachURLCommand() *cobra.Command {
+	opts := &AttachURLOptions{}
+	cmd := &cobra.Command{
+		Use:   "attach-url INC-123|ID URL",
+		Short: "attach the resource at a URL to an incident",
+		Long: `attach the resource at a URL to an incident.
+
+The kind of resource and its ID are worked out from the URL, which works for GitHub pull
+requests, PagerDuty incidents, Opsgenie alerts, Sentry issues, Statuspage incidents and
+Zendesk tickets. Attach anything else with "inc incident attachments add".`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			opts.incident, opts.url = args[0], args[1]
+
+			ctx, logger, cl, err := setup()
+			if err != nil {
+				fmt.Printf("failed to setup: %s", err)
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger, cl); err != nil {
+				logger.Log("msg", "failed to run", "error", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	return cmd
+}
+
+type AttachURLOptions struct {
+	incident string
+	url      string
+}
+
+func (o *AttachURLOptions) Run(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) error {
+	resourceType, externalID, err := parseAttachmentURL(o.url)
+	if err != nil {
+		return err
+	}
+
+	return attachResource(ctx, logger, cl, o.incident, resourceType, externalID)
+}
+
+// parseAttachmentURL works out the kind and external ID of the resource at a URL.
+func parseAttachmentURL(url string) (client.CreateRequestBody4ResourceResourceType, string, error) {
+	for _, v := range attachmentURLs {
+		if match := v.pattern.FindStringSubmatch(url); match != nil {
+			return v.resourceType, match[1], nil
+		}
+	}
+
+	return "", "", fmt.Errorf("can't tell what kind of resource %q is, use \"inc incident attachments add\" with --resource-type and --external-id instead", url)
+}
+
+func attachResource(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, incident string, resourceType client.CreateRequestBody4ResourceResourceType, externalID string) error {
+	if err := RequireRoles(ctx, logger, cl, client.IdentityV1RolesIncidentEditor); err != nil {
+		return err
+	}
+
+	id, err := FindIncidentID(ctx, logger, cl, incident)
+	if err != nil {
+		return fmt.Errorf("failed to find incident: %s", err)
+	}
+
+	res, err := CreateIncidentAttachment(ctx, logger, cl, id, resourceType, externalID)
+	if err != nil {
+		return fmt.Errorf("failed to add incident attachment: %s", err)
+	}
+
+	if err := serialize(res); err != nil {
+		return fmt.Errorf("failed to marshal json: %q", err)
+	}
+
+	return nil
+}
+
+func NewRemoveIncidentAttachmentCommand() *cobra.Command {
+	opts := &RemoveIncidentAttachmentOptions{}
+	cmd := &cobra.Command{
+		Use:   "remove INC-123|ID ATTACHMENT",
+		Short: "remove an attachment from an incident, by id, external id or permalink",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			opts.incident, opts.attachment = args[0], args[1]
+
+			ctx, logger, cl, err := setup()
+			if err != nil {
+				fmt.Printf("failed to setup: %s", err)
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger, cl); err != nil {
+				logger.Log("msg", "failed to run", "error", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	return cmd
+}
+
+type RemoveIncidentAttachmentOptions struct {
+	incident   string
+	attachment string
+}
+
+func (o *RemoveIncidentAttachmentOptions) Run(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) error {
+	if err := RequireRoles(ctx, logger, cl, client.IdentityV1RolesIncidentEditor); err != nil {
+		return err
+	}
+
+	id, err := FindIncidentID(ctx, logger, cl, o.incident)
+	if err != nil {
+		return fmt.Errorf("failed to find incident: %s", err)
+	}
+
+	attachment, err := FindIncidentAttachment(ctx, logger, cl, id, o.attachment)
+	if err != nil {
+		return fmt.Errorf("failed to find incident attachment: %s", err)
+	}
+
+	if err := DeleteIncidentAttachment(ctx, logger, cl, attachment.Id); err != nil {
+		return fmt.Errorf("failed to remove incident attachment: %s", err)
+	}
+
+	logger.Log("msg", "removed incident attachment", "resource_type", attachment.Resource.ResourceType, "external_id", attachment.Resource.ExternalId, "id", attachment.Id)
+	return nil
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/alexeldeib/incli/client"
+)
+
+func TestParseAttachmentURL(t *testing.T) {
+	tests := []struct {
+		url          string
+		resourceType client.CreateRequestBody4ResourceResourceType
+		externalID   string
+		wantErr      bool
+	}{
+		{
+			url:          "https://github.com/acme/api/pull/42/files",
+			resourceType: client.CreateRequestBody4ResourceResourceTypeGithubPullRequest,
+			externalID:   "https://github.com/acme/api/pull/42",
+		},
+		{
+			url:          "https://acme.pagerduty.com/incidents/Q1W2E3R4",
+			resourceType: client.CreateRequestBody4ResourceResourceTypePagerDutyIncident,
+			externalID:   "Q1W2E3R4",
+		},
+		{
+			url:          "https://acme.app.opsgenie.com/alert/detail/1a2b3c4d-0000-1111-2222-333344445555/details",
+			resourceType: client.CreateRequestBody4ResourceResourceTypeOpsgenieAlert,
+			externalID:   "1a2b3c4d-0000-1111-2222-333344445555",
+		},
+		{
+			url:          "https://acme.sentry.io/issues/4567/",
+			resourceType: client.CreateRequestBody4ResourceResourceTypeSentryIssue,
+			externalID:   "4567",
+		},
+		{
+			url:          "https://sentry.io/organizations/acme/issues/4567/?project=1",
+			resourceType: client.CreateRequestBody4ResourceResourceTypeSentryIssue,
+			externalID:   "4567",
+		},
+		{
+			url:          "https://manage.statuspage.io/pages/abc123/incidents/x9y8z7",
+			resourceType: client.CreateRequestBody4ResourceResourceTypeAtlassianStatuspageIncident,
+			externalID:   "x9y8z7",
+		},
+		{
+			url:          "https://acme.zendesk.com/agent/tickets/991",
+			resourceType: client.CreateRequestBody4ResourceResourceTypeZendeskTicket,
+			externalID:   "991",
+		},
+		{url: "https://github.com/acme/api/issues/42", wantErr: true},
+		{url: "http://acme.zendesk.com/agent/tickets/991", wantErr: true},
+		{url: "not a url", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			resourceType, externalID, err := parseAttachmentURL(tt.url)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseAttachmentURL(%q) error = %v, wantErr %v", tt.url, err, tt.wantErr)
+			}
+			if resourceType != tt.resourceType || externalID != tt.externalID {
+				t.Errorf("parseAttachmentURL(%q) = %q, %q, want %q, %q", tt.url, resourceType, externalID, tt.resourceType, tt.externalID)
+			}
+		})
+	}
+}
@@ -0,0 +1,344 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/alexeldeib/incli/client"
+	kitlog "github.com/go-kit/log"
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+)
+
+// incidentEdit is the change a bulk edit makes to one incident.
+type incidentEdit struct {
+	incidentID string
+	reference  string
+	fields     map[string]string
+	entries    []client.CustomFieldEntryPayloadV1
+}
+
+// fingerprint identifies the fields and values of an edit, so a state file from a
+// different edit never causes incidents to be skipped.
+func (e incidentEdit) fingerprint() string {
+	lines := lo.MapToSlice(e.fields, func(k, v string) string { return k + "=" + v })
+	sort.Strings(lines)
+
+	sum := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+	return hex.EncodeToString(sum[:8])
+}
+
+func (o *PatchIncidentOptions) runBulk(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) error {
+	if o.where != "" && o.file != "" {
+		return fmt.Errorf("only one of --where or --file may be specified")
+	}
+
+	if o.incidentReference > 0 || o.incidentID != "" {
+		return fmt.Errorf("an incident can't be given with --where or --file")
+	}
+
+	if o.where != "" && len(o.customFields) == 0 {
+		return fmt.Errorf("at least one edit field must be specified with --where")
+	}
+
+	if o.file != "" && len(o.customFields) > 0 {
+		return fmt.Errorf("--field can't be combined with --file, the file lists the fields")
+	}
+
+	if o.concurrency < 1 {
+		return fmt.Errorf("--concurrency must be at least 1: %d", o.concurrency)
+	}
+
+	if !o.dryRun {
+		if err := RequireRoles(ctx, logger, cl, client.IdentityV1RolesIncidentEditor); err != nil {
+			return err
+		}
+	}
+
+	var (
+		edits []incidentEdit
+		err   error
+	)
+	if o.where != "" {
+		edits, err = planWhereEdits(ctx, logger, cl, o.where, parseFieldFlags(o.customFields))
+	} else {
+		edits, err = planFileEdits(ctx, logger, cl, o.file)
+	}
+	if err != nil {
+		return err
+	}
+
+	resolver, err := newCustomFieldResolver(ctx, logger, cl)
+	if err != nil {
+		return fmt.Errorf("failed to find custom fields: %s", err)
+	}
+
+	// resolve every field and value up front, so a typo fails before anything changes
+	for i := range edits {
+		edits[i].entries, err = resolver.entries(ctx, logger, cl, edits[i].fields)
+		if err != nil {
+			return fmt.Errorf("failed to plan edit of %s: %s", edits[i].reference, err)
+		}
+	}
+
+	progress, err := openEditProgress(o.state, o.dryRun)
+	if err != nil {
+		return err
+	}
+	defer progress.Close()
+
+	pending := lo.Filter(edits, func(edit incidentEdit, _ int) bool { return !progress.isDone(edit) })
+	if skipped := len(edits) - len(pending); skipped > 0 {
+		logger.Log("msg", "skipping incidents the state file records as edited", "count", skipped, "state", o.state)
+	}
+
+	if o.dryRun {
+		rows := [][]string{}
+		for _, edit := range pending {
+			for _, field := range lo.Keys(edit.fields) {
+				rows = append(rows, []string{edit.reference, field, lo.Ternary(edit.fields[field] != "", edit.fields[field], "(cleared)")})
+			}
+		}
+		sort.SliceStable(rows, func(i, j int) bool {
+			return rows[i][0] < rows[j][0] || (rows[i][0] == rows[j][0] && rows[i][1] < rows[j][1])
+		})
+
+		logger.Log("msg", "dry run, no incidents were edited", "incidents", len(pending))
+		return writeTable([]string{"incident", "field", "value"}, rows)
+	}
+
+	if len(pending) == 0 {
+		logger.Log("msg", "no incidents to edit")
+		return nil
+	}
+
+	edited, failed := applyEdits(ctx, logger, cl, pending, o.concurrency, progress)
+	logger.Log("msg", "bulk edit finished", "edited", edited, "failed", failed, "not_started", len(pending)-edited-failed)
+
+	if ctx.Err() != nil {
+		return fmt.Errorf("interrupted after editing %d of %d incidents%s", edited, len(pending), lo.Ternary(o.state != "", ", rerun with the same --state to resume", ""))
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to edit %d of %d incidents%s", failed, len(pending), lo.Ternary(o.state != "", ", rerun with the same --state to retry them", ""))
+	}
+
+	return nil
+}
+
+// applyEdits edits incidents with at most concurrency requests in flight, starting no
+// more once ctx is cancelled, and returns how many were edited and how many failed.
+func applyEdits(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, edits []incidentEdit, concurrency int, progress *editProgress) (int, int) {
+	var (
+		wg             sync.WaitGroup
+		mu             sync.Mutex
+		edited, failed int
+		slots          = make(chan struct{}, concurrency)
+	)
+
+	for _, edit := range edits {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(edit incidentEdit) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			_, err := UpdateIncident(ctx, logger, cl, edit.incidentID, client.IncidentEditPayloadV2{CustomFieldEntries: &edit.entries}, false)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err != nil {
+				failed++
+				logger.Log("msg", "failed to edit incident", "incident", edit.reference, "error", err)
+				return
+			}
+
+			edited++
+			logger.Log("msg", "edited incident", "incident", edit.reference, "id", edit.incidentID)
+			if err := progress.markDone(edit); err != nil {
+				logger.Log("msg", "failed to record progress", "incident", edit.reference, "error", err)
+			}
+		}(edit)
+	}
+
+	wg.Wait()
+	return edited, failed
+}
+
+// planWhereEdits sets the same fields on every incident matching the --where filter.
+func planWhereEdits(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, where string, fields map[string]string) ([]incidentEdit, error) {
+	filter, err := parseIncidentFilter(where)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --where: %s", err)
+	}
+
+	if err := filter.resolve(ctx, logger, cl); err != nil {
+		return nil, fmt.Errorf("invalid --where: %s", err)
+	}
+
+	edits := []incidentEdit{}
+	err = WalkIncidents(ctx, logger, cl, filter.params(), func(incident client.IncidentV2) error {
+		if filter.matches(incident) {
+			edits = append(edits, incidentEdit{incidentID: incident.Id, reference: incident.Reference, fields: fields})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list incidents: %s", err)
+	}
+
+	return edits, nil
+}
+
+// planFileEdits reads a CSV file with reference, field and value columns, in any order
+// and alongside any others, grouping the rows of each incident into one edit.
+func planFileEdits(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, path string) ([]incidentEdit, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "opening edits file")
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	header, err := r.Read()
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading header of %s", path)
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"reference", "field", "value"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("%s has no %s column, the header must name reference, field and value columns", path, name)
+		}
+	}
+
+	edits := []incidentEdit{}
+	byReference := map[string]int{}
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading %s", path)
+		}
+
+		line, _ := r.FieldPos(0)
+		reference := strings.TrimSpace(record[columns["reference"]])
+		field := strings.TrimSpace(record[columns["field"]])
+		if reference == "" || field == "" {
+			return nil, fmt.Errorf("%s:%d: reference and field must not be empty", path, line)
+		}
+
+		i, ok := byReference[reference]
+		if !ok {
+			id, err := FindIncidentID(ctx, logger, cl, reference)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: failed to find incident: %s", path, line, err)
+			}
+			i = len(edits)
+			byReference[reference] = i
+			edits = append(edits, incidentEdit{incidentID: id, reference: reference, fields: map[string]string{}})
+		}
+
+		if _, ok := edits[i].fields[field]; ok {
+			return nil, fmt.Errorf("%s:%d: field %q of %s is already set by an earlier row", path, line, field, reference)
+		}
+		edits[i].fields[field] = record[columns["value"]]
+	}
+
+	return edits, nil
+}
+
+// editProgress records the incidents a bulk edit has finished in a state file, one per
+// line, so rerunning the same edit skips them. A nil progress records nothing.
+type editProgress struct {
+	mu   sync.Mutex
+	file *os.File
+	done map[string]bool
+}
+
+// openEditProgress reads the state file at path, creating it unless readOnly is set, in
+// which case a missing file records nothing.
+func openEditProgress(path string, readOnly bool) (*editProgress, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	flag := os.O_RDWR | os.O_CREATE | os.O_APPEND
+	if readOnly {
+		flag = os.O_RDONLY
+	}
+
+	file, err := os.OpenFile(path, flag, 0o644)
+	if readOnly && errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "opening state file")
+	}
+
+	progress := &editProgress{file: file, done: map[string]bool{}}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		progress.done[strings.TrimSpace(scanner.Text())] = true
+	}
+	if err := scanner.Err(); err != nil {
+		file.Close()
+		return nil, errors.Wrap(err, "reading state file")
+	}
+
+	return progress, nil
+}
+
+func editProgressKey(edit incidentEdit) string {
+	return edit.incidentID + " " + edit.fingerprint()
+}
+
+func (p *editProgress) isDone(edit incidentEdit) bool {
+	if p == nil {
+		return false
+	}
+	return p.done[editProgressKey(edit)]
+}
+
+func (p *editProgress) markDone(edit incidentEdit) error {
+	if p == nil {
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, err := fmt.Fprintln(p.file, editProgressKey(edit)); err != nil {
+		return errors.Wrap(err, "writing state file")
+	}
+	return errors.Wrap(p.file.Sync(), "writing state file")
+}
+
+func (p *editProgress) Close() error {
+	if p == nil {
+		return nil
+	}
+	return p.file.Close()
+}
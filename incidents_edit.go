@@ -15,9 +15,28 @@ func NewPatchIncidentsCommand() *cobra.Command {
 	opts := &PatchIncidentOptions{}
 
 	cmd := &cobra.Command{
-		Use:   "edit",
-		Short: "edit an incident",
+		Use:   "edit [INC-123|ID]",
+		Short: "edit an incident, or many at once with --where or --file",
+		Long: `edit an incident, or many at once with --where or --file.
+
+--where edits every incident matching all of its conditions, e.g.
+"status_category=closed,severity=Critical|Major". Conditions test status,
+status_category, severity, incident_type or mode, take one or more values separated
+by |, and use != to exclude values.
+
+--file reads a CSV file with reference, field and value columns, one row per field of
+an incident to set.
+
+Bulk edits check every field and value before changing anything, then edit incidents
+in parallel and report each one. Pass --state to record finished incidents in a file,
+so a run that was interrupted or partly failed can be resumed by rerunning it.`,
+		Args: cobra.MaximumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
+			if err := opts.Complete(args); err != nil {
+				fmt.Printf("invalid arguments: %s", err)
+				os.Exit(1)
+			}
+
 			ctx, logger, cl, err := setup()
 			if err != nil {
 				fmt.Printf("failed to setup: %s", err)
@@ -31,51 +50,65 @@ func NewPatchIncidentsCommand() *cobra.Command {
 		},
 	}
 
-	cmd.Flags().StringVar(&opts.incidentID, "id", "", "incident ID, e.g. 01HE6...")
-	cmd.Flags().IntVar(&opts.incidentReference, "ref", -1, "incident reference number, e.g. 27 for INC-27")
+	cmd.Flags().StringVar(&opts.incidentID, "id", "", "incident ID, e.g. 01HE6... may also be given as the only argument")
+	cmd.Flags().StringVar(&opts.incidentRef, "ref", "", "incident reference, e.g. 27, INC-27 or a permalink. may also be given as the only argument")
 	cmd.Flags().StringSliceVar(&opts.customFields, "field", nil, "custom field to patch, e.g. --field foo=bar --field baz=qux. --field foo=bar=baz sets field `foo` to `bar=baz`")
+	cmd.Flags().StringVar(&opts.where, "where", "", "edit every incident matching these conditions, e.g. status_category=closed,severity=Critical")
+	cmd.Flags().StringVarP(&opts.file, "file", "f", "", "edit the incidents listed in this CSV file of reference, field and value columns")
+	cmd.Flags().IntVar(&opts.concurrency, "concurrency", 4, "how many incidents a bulk edit changes at once")
+	cmd.Flags().BoolVar(&opts.dryRun, "dry-run", false, "only print the changes a bulk edit would make")
+	cmd.Flags().StringVar(&opts.state, "state", "", "file recording the incidents a bulk edit has finished, which are skipped when it is rerun")
 
 	return cmd
 }
 
 type PatchIncidentOptions struct {
+	incidentRef       string
 	incidentReference int
 	incidentID        string
 	customFields      []string
+	where             string
+	file              string
+	concurrency       int
+	dryRun            bool
+	state             string
+}
+
+func (o *PatchIncidentOptions) Complete(args []string) error {
+	id, reference, err := resolveIncidentArgs(args, o.incidentID, o.incidentRef)
+	if err != nil {
+		return err
+	}
+	o.incidentID, o.incidentReference = id, reference
+
+	return nil
 }
 
 func (o *PatchIncidentOptions) Run(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) error {
-	if o.incidentReference != -1 && o.incidentID != "" {
-		return fmt.Errorf("exactly one of --id or --ref may be specified")
+	if o.where != "" || o.file != "" {
+		return o.runBulk(ctx, logger, cl)
 	}
 
-	if o.incidentReference == -1 && o.incidentID == "" {
-		return fmt.Errorf("exactly one of --id or --ref must be specified")
+	if o.dryRun || o.state != "" {
+		return fmt.Errorf("--dry-run and --state only apply to --where and --file")
 	}
 
-	if o.incidentReference != -1 && o.incidentReference < 0 {
-		return fmt.Errorf("incident --ref must be positive integer: %q", o.incidentReference)
+	if o.incidentReference > 0 && o.incidentID != "" {
+		return fmt.Errorf("exactly one of --id or --ref may be specified")
+	}
+
+	if o.incidentReference == 0 && o.incidentID == "" {
+		return fmt.Errorf("exactly one of --id, --ref, --where or --file must be specified")
 	}
 
 	if len(o.customFields) == 0 {
 		return fmt.Errorf("at least one edit field must be specified")
 	}
 
-	customFieldsMap := map[string]string{}
-	for _, v := range o.customFields {
-		parts := strings.Split(v, "=")
-		if len(parts) < 1 {
-			return fmt.Errorf("invalid custom field: %q", v)
-		}
+	customFieldsMap := parseFieldFlags(o.customFields)
 
-		switch len(parts) {
-		case 1:
-			customFieldsMap[parts[0]] = "" // field reset
-		case 2:
-			customFieldsMap[parts[0]] = parts[1]
-		default: // field value contains equal signs
-			customFieldsMap[parts[0]] = strings.Join(parts[1:], "=")
-		}
+	if err := RequireRoles(ctx, logger, cl, client.IdentityV1RolesIncidentEditor); err != nil {
+		return err
 	}
 
 	if o.incidentReference > 0 {
@@ -91,9 +124,9 @@ func (o *PatchIncidentOptions) Run(ctx context.Context, logger kitlog.Logger, cl
 		return nil
 	}
 
-	incident, err := ShowIncidentByID(ctx, logger, cl, o.incidentID)
+	incident, err := EditIncident(ctx, logger, cl, o.incidentID, customFieldsMap)
 	if err != nil {
-		return fmt.Errorf("failed to list incidents: %q", err)
+		return fmt.Errorf("failed to edit incident: %q", err)
 	}
 
 	if err := serialize(incident); err != nil {
@@ -102,3 +135,23 @@ func (o *PatchIncidentOptions) Run(ctx context.Context, logger kitlog.Logger, cl
 
 	return nil
 }
+
+// parseFieldFlags turns --field NAME=VALUE flags into a map of field name to value,
+// where everything after the first equal sign is the value.
+func parseFieldFlags(fields []string) map[string]string {
+	customFieldsMap := map[string]string{}
+	for _, v := range fields {
+		parts := strings.Split(v, "=")
+
+		switch len(parts) {
+		case 1:
+			customFieldsMap[parts[0]] = "" // field reset
+		case 2:
+			customFieldsMap[parts[0]] = parts[1]
+		default: // field value contains equal signs
+			customFieldsMap[parts[0]] = strings.Join(parts[1:], "=")
+		}
+	}
+
+	return customFieldsMap
+}
@@ -0,0 +1,430 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/alexeldeib/incli/client"
+	kitlog "github.com/go-kit/log"
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+)
+
+const (
+	exportFormatCSV        = "csv"
+	exportFormatJSONL      = "jsonl"
+	exportFormatClickHouse = "native-clickhouse-tsv"
+)
+
+var exportFormats = []string{exportFormatCSV, exportFormatJSONL, exportFormatClickHouse}
+
+// exportTimeLayout is how times are written to csv and tsv, which ClickHouse parses into
+// a DateTime64 as is.
+const exportTimeLayout = "2006-01-02 15:04:05.000"
+
+func NewIncidentExportCommand() *cobra.Command {
+	opts := &IncidentExportOptions{}
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "export incidents as flat rows for loading into a database",
+		Long: `export incidents as flat rows for loading into a database.
+
+Every incident becomes one row, written as soon as its page is fetched. After the
+fixed columns come one per incident timestamp, named timestamp_<name>, one per incident
+role holding the assignee, named role_<name>, and one per custom field holding its
+values joined by ", ", named custom_field_<name>. Names are lower snake case, and the
+columns are always in the same order for the same timestamps, roles and custom fields,
+so exports can be appended to one table.
+
+csv and jsonl write times as UTC, jsonl in RFC 3339. native-clickhouse-tsv is
+ClickHouse's TabSeparatedWithNames format, with \N for missing values.
+
+--schema writes a ClickHouse CREATE TABLE statement for the columns. It uses a
+ReplacingMergeTree on updated_at, so reloading incidents keeps their latest version.
+
+  inc incident export --format native-clickhouse-tsv --schema schema.sql > incidents.tsv
+  clickhouse-client < schema.sql
+  clickhouse-client --query "INSERT INTO incidents FORMAT TabSeparatedWithNames" < incidents.tsv`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, logger, cl, err := setup()
+			if err != nil {
+				fmt.Printf("failed to setup: %s", err)
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger, cl); err != nil {
+				logger.Log("msg", "failed to run", "error", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.since, "since", "", "only incidents created since, a duration such as 30d, 2w or 12h, or a date such as 2024-01-31. every incident when empty")
+	cmd.Flags().StringVar(&opts.where, "where", "", "only incidents matching these conditions, e.g. \"mode=standard,status_category!=declined\"")
+	cmd.Flags().StringVar(&opts.format, "format", exportFormatCSV, "output format, one of: csv, jsonl, native-clickhouse-tsv")
+	cmd.Flags().StringVar(&opts.schema, "schema", "", "path to write a ClickHouse CREATE TABLE statement for the columns to")
+	cmd.Flags().StringVar(&opts.table, "table", "incidents", "table name used by --schema")
+
+	return cmd
+}
+
+type IncidentExportOptions struct {
+	since  string
+	where  string
+	format string
+	schema string
+	table  string
+}
+
+// exportColumn is one column of an export. value returns nil when the incident has no
+// value, or a string, int64, float64 or time.Time.
+type exportColumn struct {
+	name       string
+	clickhouse string
+	value      func(client.IncidentV2) any
+}
+
+func (o *IncidentExportOptions) Run(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) error {
+	if !lo.Contains(exportFormats, o.format) {
+		return fmt.Errorf("--format must be one of %v: %q", exportFormats, o.format)
+	}
+
+	var since time.Time
+	if o.since != "" {
+		var err error
+		if since, err = parseSince(o.since, time.Now()); err != nil {
+			return fmt.Errorf("invalid --since: %s", err)
+		}
+	}
+
+	var filter *incidentFilter
+	params := client.IncidentsV2ListParams{}
+	if o.where != "" {
+		var err error
+		if filter, err = parseIncidentFilter(o.where); err != nil {
+			return fmt.Errorf("invalid --where: %s", err)
+		}
+		if err := filter.resolve(ctx, logger, cl); err != nil {
+			return fmt.Errorf("invalid --where: %s", err)
+		}
+		params = filter.params()
+	}
+
+	columns, err := incidentExportColumns(ctx, logger, cl)
+	if err != nil {
+		return err
+	}
+
+	if o.schema != "" {
+		if err := os.WriteFile(o.schema, []byte(clickhouseSchema(o.table, columns)), 0o644); err != nil {
+			return errors.Wrap(err, "writing schema")
+		}
+	}
+
+	out := bufio.NewWriter(os.Stdout)
+	w := newExportWriter(out, o.format, columns)
+	if err := w.header(); err != nil {
+		return err
+	}
+
+	count := 0
+	err = WalkIncidents(ctx, logger, cl, params, func(incident client.IncidentV2) error {
+		if incident.CreatedAt.Before(since) || (filter != nil && !filter.matches(incident)) {
+			return nil
+		}
+
+		values := lo.Map(columns, func(c exportColumn, _ int) any { return c.value(incident) })
+		if err := w.row(values); err != nil {
+			return err
+		}
+		count++
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list incidents: %s", err)
+	}
+
+	if err := w.flush(); err != nil {
+		return err
+	}
+	if err := out.Flush(); err != nil {
+		return errors.Wrap(err, "failed to write output")
+	}
+
+	logger.Log("msg", "exported incidents", "count", count, "columns", len(columns))
+	return nil
+}
+
+// incidentExportColumns lists the fixed columns, then the timestamp, role and custom
+// field columns in a stable order.
+func incidentExportColumns(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) ([]exportColumn, error) {
+	timestamps, err := ListAllIncidentTimestamps(ctx, logger, cl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list incident timestamps: %s", err)
+	}
+
+	roles, err := ListAllIncidentRoles(ctx, logger, cl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list incident roles: %s", err)
+	}
+	sort.SliceStable(roles, func(i, j int) bool { return roles[i].Name < roles[j].Name })
+
+	fields, err := ListAllCustomFields(ctx, logger, cl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list custom fields: %s", err)
+	}
+	sort.SliceStable(fields, func(i, j int) bool { return fields[i].Name < fields[j].Name })
+
+	optional := func(v *string) any {
+		if v == nil || *v == "" {
+			return nil
+		}
+		return *v
+	}
+	optionalFloat := func(v *float64) any {
+		if v == nil {
+			return nil
+		}
+		return *v
+	}
+
+	columns := []exportColumn{
+		{"id", "String", func(v client.IncidentV2) any { return v.Id }},
+		{"reference", "String", func(v client.IncidentV2) any { return v.Reference }},
+		{"name", "String", func(v client.IncidentV2) any { return v.Name }},
+		{"status", "String", func(v client.IncidentV2) any { return v.IncidentStatus.Name }},
+		{"status_category", "LowCardinality(String)", func(v client.IncidentV2) any { return string(v.IncidentStatus.Category) }},
+		{"severity", "Nullable(String)", func(v client.IncidentV2) any {
+			if v.Severity == nil {
+				return nil
+			}
+			return v.Severity.Name
+		}},
+		{"severity_rank", "Nullable(Int64)", func(v client.IncidentV2) any {
+			if v.Severity == nil {
+				return nil
+			}
+			return v.Severity.Rank
+		}},
+		{"incident_type", "Nullable(String)", func(v client.IncidentV2) any {
+			if v.IncidentType == nil {
+				return nil
+			}
+			return v.IncidentType.Name
+		}},
+		{"mode", "LowCardinality(String)", func(v client.IncidentV2) any { return string(v.Mode) }},
+		{"visibility", "LowCardinality(String)", func(v client.IncidentV2) any { return string(v.Visibility) }},
+		{"summary", "Nullable(String)", func(v client.IncidentV2) any { return optional(v.Summary) }},
+		{"permalink", "Nullable(String)", func(v client.IncidentV2) any { return optional(v.Permalink) }},
+		{"slack_channel_name", "Nullable(String)", func(v client.IncidentV2) any { return optional(v.SlackChannelName) }},
+		{"postmortem_document_url", "Nullable(String)", func(v client.IncidentV2) any { return optional(v.PostmortemDocumentUrl) }},
+		{"creator", "Nullable(String)", func(v client.IncidentV2) any {
+			if creator := actorLabel(v.Creator); creator != "" {
+				return creator
+			}
+			return nil
+		}},
+		{"created_at", "DateTime64(3, 'UTC')", func(v client.IncidentV2) any { return v.CreatedAt }},
+		{"updated_at", "DateTime64(3, 'UTC')", func(v client.IncidentV2) any { return v.UpdatedAt }},
+		{"workload_minutes_total", "Nullable(Float64)", func(v client.IncidentV2) any { return optionalFloat(v.WorkloadMinutesTotal) }},
+		{"workload_minutes_working", "Nullable(Float64)", func(v client.IncidentV2) any { return optionalFloat(v.WorkloadMinutesWorking) }},
+		{"workload_minutes_late", "Nullable(Float64)", func(v client.IncidentV2) any { return optionalFloat(v.WorkloadMinutesLate) }},
+		{"workload_minutes_sleeping", "Nullable(Float64)", func(v client.IncidentV2) any { return optionalFloat(v.WorkloadMinutesSleeping) }},
+	}
+
+	names := lo.SliceToMap(columns, func(c exportColumn) (string, bool) { return c.name, true })
+	// a name with no letters or digits, or one clashing with an earlier column, falls
+	// back to the ID, so columns keep their names as fields are added
+	uniqueName := func(prefix, name, id string) string {
+		unique := prefix + snakeCase(name)
+		if unique == prefix || names[unique] {
+			unique = prefix + snakeCase(id)
+		}
+		names[unique] = true
+		return unique
+	}
+
+	for _, timestamp := range timestamps {
+		id := timestamp.Id
+		columns = append(columns, exportColumn{uniqueName("timestamp_", timestamp.Name, id), "Nullable(DateTime64(3, 'UTC'))", func(v client.IncidentV2) any {
+			for _, value := range lo.FromPtr(v.IncidentTimestampValues) {
+				if value.IncidentTimestamp.Id == id && value.Value != nil && value.Value.Value != nil {
+					return *value.Value.Value
+				}
+			}
+			return nil
+		}})
+	}
+
+	for _, role := range roles {
+		id := role.Id
+		columns = append(columns, exportColumn{uniqueName("role_", role.Name, id), "Nullable(String)", func(v client.IncidentV2) any {
+			for _, assignment := range v.IncidentRoleAssignments {
+				if assignment.Role.Id == id && assignment.Assignee != nil {
+					return userLabel(assignment.Assignee)
+				}
+			}
+			return nil
+		}})
+	}
+
+	for _, field := range fields {
+		id := field.Id
+		columns = append(columns, exportColumn{uniqueName("custom_field_", field.Name, id), "Nullable(String)", func(v client.IncidentV2) any {
+			for _, entry := range v.CustomFieldEntries {
+				if entry.CustomField.Id == id {
+					if value := customFieldEntryValue(entry); value != "" {
+						return value
+					}
+				}
+			}
+			return nil
+		}})
+	}
+
+	return columns, nil
+}
+
+func clickhouseSchema(table string, columns []exportColumn) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "CREATE TABLE IF NOT EXISTS `%s`\n(\n", table)
+	for i, c := range columns {
+		fmt.Fprintf(&b, "    `%s` %s%s\n", c.name, c.clickhouse, lo.Ternary(i < len(columns)-1, ",", ""))
+	}
+	b.WriteString(")\nENGINE = ReplacingMergeTree(updated_at)\nORDER BY (created_at, id);\n")
+	return b.String()
+}
+
+// exportWriter writes rows in one of the export formats.
+type exportWriter struct {
+	out     io.Writer
+	format  string
+	columns []exportColumn
+	csv     *csv.Writer
+}
+
+func newExportWriter(out io.Writer, format string, columns []exportColumn) *exportWriter {
+	w := &exportWriter{out: out, format: format, columns: columns}
+	if format == exportFormatCSV {
+		w.csv = csv.NewWriter(out)
+	}
+	return w
+}
+
+func (w *exportWriter) header() error {
+	names := lo.Map(w.columns, func(c exportColumn, _ int) string { return c.name })
+	switch w.format {
+	case exportFormatCSV:
+		return errors.Wrap(w.csv.Write(names), "failed to write output")
+	case exportFormatClickHouse:
+		return w.tsvLine(lo.Map(names, func(name string, _ int) string { return escapeTSV(name) }))
+	}
+	return nil
+}
+
+func (w *exportWriter) row(values []any) error {
+	switch w.format {
+	case exportFormatCSV:
+		cells := lo.Map(values, func(v any, _ int) string { return formatExportValue(v) })
+		return errors.Wrap(w.csv.Write(cells), "failed to write output")
+	case exportFormatClickHouse:
+		return w.tsvLine(lo.Map(values, func(v any, _ int) string {
+			if v == nil {
+				return `\N`
+			}
+			return escapeTSV(formatExportValue(v))
+		}))
+	}
+
+	// keep the column order, which a map would lose
+	var b strings.Builder
+	b.WriteString("{")
+	for i, c := range w.columns {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		key, _ := json.Marshal(c.name)
+		value := values[i]
+		if t, ok := value.(time.Time); ok {
+			value = t.UTC().Format(time.RFC3339Nano)
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal json")
+		}
+		fmt.Fprintf(&b, "%s:%s", key, data)
+	}
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w.out, b.String())
+	return errors.Wrap(err, "failed to write output")
+}
+
+func (w *exportWriter) tsvLine(cells []string) error {
+	_, err := io.WriteString(w.out, strings.Join(cells, "\t")+"\n")
+	return errors.Wrap(err, "failed to write output")
+}
+
+func (w *exportWriter) flush() error {
+	if w.csv != nil {
+		w.csv.Flush()
+		return errors.Wrap(w.csv.Error(), "failed to write output")
+	}
+	return nil
+}
+
+// formatExportValue renders a value for csv and tsv, a missing one as empty.
+func formatExportValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		return v.UTC().Format(exportTimeLayout)
+	}
+	return fmt.Sprint(v)
+}
+
+var tsvEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+
+// escapeTSV escapes a value for ClickHouse's TabSeparated formats.
+func escapeTSV(s string) string {
+	return tsvEscaper.Replace(s)
+}
+
+// snakeCase turns a name such as "Reported at" or "Customer Impact (%)" into a column
+// name such as reported_at or customer_impact. Letters and digits of any script are
+// kept, so "Überprüfung" becomes überprüfung, and a name without any becomes empty.
+func snakeCase(name string) string {
+	var b strings.Builder
+	underscore := false
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if underscore && b.Len() > 0 {
+				b.WriteByte('_')
+			}
+			b.WriteRune(r)
+			underscore = false
+		} else {
+			underscore = true
+		}
+	}
+	return b.String()
+}
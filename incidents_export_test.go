@@ -0,0 +1,27 @@
+package main
+
+import "testing"
+
+func TestSnakeCase(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{input: "Reported at", want: "reported_at"},
+		{input: "Customer Impact (%)", want: "customer_impact"},
+		{input: "  Leading and trailing  ", want: "leading_and_trailing"},
+		{input: "P1 -- P2", want: "p1_p2"},
+		{input: "Ünïcode", want: "ünïcode"},
+		{input: "影響範囲", want: "影響範囲"},
+		{input: "🔥", want: ""},
+		{input: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := snakeCase(tt.input); got != tt.want {
+				t.Errorf("snakeCase(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/alexeldeib/incli/client"
 	kitlog "github.com/go-kit/log"
@@ -11,63 +12,153 @@ import (
 )
 
 type GetIncidentOptions struct {
+	incidentRef       string
 	incidentReference int
 	incidentID        string
+	output            string
+	limit             int
+	pageSize          int64
+	where             string
+	watch             bool
+	interval          time.Duration
+}
+
+func (o *GetIncidentOptions) Complete(args []string) error {
+	id, reference, err := resolveIncidentArgs(args, o.incidentID, o.incidentRef)
+	if err != nil {
+		return err
+	}
+	o.incidentID, o.incidentReference = id, reference
+
+	return nil
 }
 
 func (o *GetIncidentOptions) Run(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) error {
-	if o.incidentReference != -1 && o.incidentID != "" {
+	if o.incidentReference > 0 && o.incidentID != "" {
 		return fmt.Errorf("only one of --id or --ref may be specified")
 	}
 
-	if o.incidentReference != -1 && o.incidentReference < 0 {
-		return fmt.Errorf("incident --ref must be positive integer: %q", o.incidentReference)
+	if o.limit < 0 {
+		return fmt.Errorf("--limit must not be negative: %d", o.limit)
 	}
 
-	if o.incidentReference > 0 {
-		incident, err := ShowIncidentByReference(ctx, logger, cl, o.incidentReference)
-		if err != nil {
-			return fmt.Errorf("failed to list incidents: %s", err)
+	if o.pageSize < 1 || o.pageSize > defaultPageSize {
+		return fmt.Errorf("--page-size must be between 1 and %d: %d", defaultPageSize, o.pageSize)
+	}
+
+	single := o.incidentReference > 0 || o.incidentID != ""
+	if single && o.where != "" {
+		return fmt.Errorf("--where can't be combined with an incident")
+	}
+
+	var filter *incidentFilter
+	if o.where != "" {
+		var err error
+		if filter, err = parseIncidentFilter(o.where); err != nil {
+			return fmt.Errorf("invalid --where: %s", err)
+		}
+		if err := filter.resolve(ctx, logger, cl); err != nil {
+			return fmt.Errorf("invalid --where: %s", err)
 		}
+	}
 
-		if err := serialize(incident); err != nil {
-			return fmt.Errorf("failed to marshal json: %s", err)
+	if o.watch {
+		if single || o.limit > 0 {
+			return fmt.Errorf("--watch can't be combined with an incident or --limit")
+		}
+		if o.interval < time.Second {
+			return fmt.Errorf("--interval must be at least 1s: %s", o.interval)
 		}
+		if err := validateOutput(o.output, outputText, outputJSONL); err != nil {
+			return err
+		}
+		return o.runWatch(ctx, logger, cl, filter)
+	}
 
-		return nil
+	w, err := newRecordWriter(o.output)
+	if err != nil {
+		return err
 	}
 
-	if o.incidentID != "" {
-		incident, err := ShowIncidentByID(ctx, logger, cl, o.incidentID)
+	if single {
+		var incident *client.IncidentV2
+		if o.incidentReference > 0 {
+			incident, err = ShowIncidentByReference(ctx, logger, cl, o.incidentReference)
+		} else {
+			incident, err = ShowIncidentByID(ctx, logger, cl, o.incidentID)
+		}
 		if err != nil {
 			return fmt.Errorf("failed to list incidents: %s", err)
 		}
 
-		if err := serialize(incident); err != nil {
-			return fmt.Errorf("failed to marshal json: %s", err)
+		// a single incident is printed as an object rather than a one element array
+		if o.output == outputJSON {
+			if err := serialize(incident); err != nil {
+				return fmt.Errorf("failed to marshal json: %s", err)
+			}
+			return nil
 		}
 
-		return nil
+		if err := w.Write(incident); err != nil {
+			return err
+		}
+		return w.Close()
 	}
 
-	incidents, err := ListAllIncidents(ctx, logger, cl)
-	if err != nil {
-		return fmt.Errorf("failed to list incidents: %s", err)
+	params := client.IncidentsV2ListParams{}
+	if filter != nil {
+		params = filter.params()
 	}
+	params.PageSize = &o.pageSize
 
-	if err := serialize(incidents); err != nil {
-		return fmt.Errorf("failed to marshal json: %s", err)
+	count := 0
+	err = WalkIncidents(ctx, logger, cl, params, func(incident client.IncidentV2) error {
+		if filter != nil && !filter.matches(incident) {
+			return nil
+		}
+		if err := w.Write(incident); err != nil {
+			return err
+		}
+		if count++; o.limit > 0 && count >= o.limit {
+			return errStopWalk
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list incidents: %s", err)
 	}
 
-	return nil
+	return w.Close()
 }
 
 func NewGetIncidentCommand() *cobra.Command {
 	opts := &GetIncidentOptions{}
 	cmd := &cobra.Command{
-		Use:   "get",
+		Use:   "get [INC-123|ID]",
 		Short: "get one or all incidents",
+		Long: `get one or all incidents.
+
+--where limits the listing to incidents matching every condition, for example
+"status_category=live,severity=Critical|Major" or "status!=Closed". Conditions test
+status, status_category, severity, incident_type or mode.
+
+--watch keeps polling the incidents every --interval and prints what changed since the
+previous poll: incidents created, and changes of status, severity or custom fields. An
+incident that stops matching --where is fetched once more to report its last change,
+while one that starts matching without being new is watched from then on. Stop watching
+with Ctrl-C.`,
+		Args: cobra.MaximumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
+			if err := opts.Complete(args); err != nil {
+				fmt.Printf("invalid arguments: %s", err)
+				os.Exit(1)
+			}
+
+			// a json array never ends while watching, so default to lines instead
+			if opts.watch && !cmd.Flags().Changed("output") {
+				opts.output = outputText
+			}
+
 			ctx, logger, cl, err := setup()
 			if err != nil {
 				fmt.Printf("failed to setup: %s", err)
@@ -81,8 +172,14 @@ func NewGetIncidentCommand() *cobra.Command {
 		},
 	}
 
-	cmd.Flags().StringVar(&opts.incidentID, "id", "", "incident ID, e.g. 01HE6...")
-	cmd.Flags().IntVar(&opts.incidentReference, "ref", -1, "incident reference number, e.g. 27 for INC-27")
+	cmd.Flags().StringVar(&opts.incidentID, "id", "", "incident ID, e.g. 01HE6... may also be given as the only argument")
+	cmd.Flags().StringVar(&opts.incidentRef, "ref", "", "incident reference, e.g. 27, INC-27 or a permalink. may also be given as the only argument")
+	cmd.Flags().StringVarP(&opts.output, "output", "o", outputJSON, "output format, one of: json, jsonl. jsonl prints each incident as soon as it is fetched. --watch prints text or jsonl, defaulting to text")
+	cmd.Flags().IntVar(&opts.limit, "limit", 0, "maximum number of incidents to list, 0 for no limit")
+	cmd.Flags().Int64Var(&opts.pageSize, "page-size", defaultPageSize, "number of incidents to fetch per API request")
+	cmd.Flags().StringVar(&opts.where, "where", "", "only incidents matching these conditions, e.g. \"status_category=live,severity=Critical|Major\"")
+	cmd.Flags().BoolVar(&opts.watch, "watch", false, "keep polling the incidents and print what changes")
+	cmd.Flags().DurationVar(&opts.interval, "interval", 30*time.Second, "how often --watch polls")
 
 	return cmd
 }
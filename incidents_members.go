@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/alexeldeib/incli/client"
+	kitlog "github.com/go-kit/log"
+	"github.com/spf13/cobra"
+)
+
+func NewIncidentMembersCommand() *cobra.Command {
+	root := &cobra.Command{
+		Use:     "members",
+		Aliases: []string{"member"},
+		Short:   "manage who can access a private incident",
+	}
+
+	root.AddCommand(NewAddIncidentMembersCommand())
+	root.AddCommand(NewRevokeIncidentMembersCommand())
+
+	return root
+}
+
+func NewAddIncidentMembersCommand() *cobra.Command {
+	opts := &AddIncidentMembersOptions{}
+	cmd := &cobra.Command{
+		Use:   "add INC-123|ID",
+		Short: "give users access to a private incident",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			opts.incident = args[0]
+
+			ctx, logger, cl, err := setup()
+			if err != nil {
+				fmt.Printf("failed to setup: %s", err)
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger, cl); err != nil {
+				logger.Log("msg", "failed to run", "error", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&opts.users, "user", nil, "email, Slack user ID, name or ID of the user, may be repeated")
+
+	return cmd
+}
+
+func NewRevokeIncidentMembersCommand() *cobra.Command {
+	opts := &RevokeIncidentMembersOptions{}
+	cmd := &cobra.Command{
+		Use:   "revoke INC-123|ID",
+		Short: "take away users' access to a private incident",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			opts.incident = args[0]
+
+			ctx, logger, cl, err := setup()
+			if err != nil {
+				fmt.Printf("failed to setup: %s", err)
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger, cl); err != nil {
+				logger.Log("msg", "failed to run", "error", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&opts.users, "user", nil, "email, Slack user ID, name or ID of the user, may be repeated")
+
+	return cmd
+}
+
+type AddIncidentMembersOptions struct {
+	incident string
+	users    []string
+}
+
+func (o *AddIncidentMembersOptions) Run(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) error {
+	incidentID, users, err := resolveIncidentMembers(ctx, logger, cl, o.incident, o.users)
+	if err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		membership, err := AddIncidentMember(ctx, logger, cl, incidentID, user.Id)
+		if err != nil {
+			return fmt.Errorf("failed to add %s to incident: %s", userLabel(&user), err)
+		}
+		logger.Log("msg", "added incident member", "incident", o.incident, "user", userLabel(&user), "id", membership.Id)
+	}
+
+	return nil
+}
+
+type RevokeIncidentMembersOptions struct {
+	incident string
+	users    []string
+}
+
+func (o *RevokeIncidentMembersOptions) Run(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) error {
+	incidentID, users, err := resolveIncidentMembers(ctx, logger, cl, o.incident, o.users)
+	if err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		if err := RevokeIncidentMember(ctx, logger, cl, incidentID, user.Id); err != nil {
+			return fmt.Errorf("failed to revoke access of %s: %s", userLabel(&user), err)
+		}
+		logger.Log("msg", "revoked incident membership", "incident", o.incident, "user", userLabel(&user))
+	}
+
+	return nil
+}
+
+// resolveIncidentMembers finds the incident and every user up front, so a typo in one
+// user doesn't leave the memberships half changed.
+func resolveIncidentMembers(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, incident string, targets []string) (string, []client.UserV1, error) {
+	if len(targets) == 0 {
+		return "", nil, fmt.Errorf("at least one --user must be specified")
+	}
+
+	if err := RequireRoles(ctx, logger, cl, client.IdentityV1RolesIncidentMembershipsEditor); err != nil {
+		return "", nil, err
+	}
+
+	incidentID, err := FindIncidentID(ctx, logger, cl, incident)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to find incident: %s", err)
+	}
+
+	users := []client.UserV1{}
+	for _, target := range targets {
+		user, err := FindUser(ctx, logger, cl, target)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to find user: %s", err)
+		}
+		users = append(users, *user)
+	}
+
+	return incidentID, users, nil
+}
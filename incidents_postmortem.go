@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/alexeldeib/incli/client"
+	kitlog "github.com/go-kit/log"
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+)
+
+//go:embed postmortem.md.tmpl
+var defaultPostmortemTemplate string
+
+func NewIncidentPostmortemCommand() *cobra.Command {
+	opts := &IncidentPostmortemOptions{}
+	cmd := &cobra.Command{
+		Use:   "postmortem INC-123|ID",
+		Short: "draft a Markdown postmortem populated from an incident",
+		Long: `draft a Markdown postmortem populated from an incident.
+
+The draft is rendered from a Go text/template, a built-in one unless --template is
+given. Print the built-in template with --print-template to start your own from it.
+Templates are given:
+
+  .Incident      the incident, as returned by inc incident get
+  .Timeline      the events of inc incident timeline, each with .Time, .Kind, .Summary,
+                 .Actor and .Message. Role assignments have no .Time
+  .Updates       the incident's status and severity updates
+  .FollowUps     the incident's follow-ups
+  .Timestamps    the incident timestamps that are set, each with .Name and .Value
+  .Duration      from reported to resolved, e.g. 3h20m, empty until resolved
+  .Roles         the assigned roles, each with .Role and .Assignee
+  .CustomFields  the custom fields that are set, each with .Name and .Value
+  .GeneratedAt   when the draft was rendered
+
+and the functions time, which formats a time, user, which shows a user by email or
+name, cell, which puts text on one line and escapes it for a Markdown table, and join.`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if opts.printTemplate {
+				fmt.Print(defaultPostmortemTemplate)
+				return
+			}
+
+			if len(args) == 0 {
+				fmt.Printf("invalid arguments: an incident must be given")
+				os.Exit(1)
+			}
+			opts.incident = args[0]
+
+			ctx, logger, cl, err := setup()
+			if err != nil {
+				fmt.Printf("failed to setup: %s", err)
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger, cl); err != nil {
+				logger.Log("msg", "failed to run", "error", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.template, "template", "", "path of a text/template to render instead of the built-in one")
+	cmd.Flags().BoolVar(&opts.printTemplate, "print-template", false, "print the built-in template and exit")
+	cmd.Flags().BoolVar(&opts.utc, "utc", false, "print times in UTC rather than the local time zone")
+
+	return cmd
+}
+
+type IncidentPostmortemOptions struct {
+	incident      string
+	template      string
+	printTemplate bool
+	utc           bool
+}
+
+type postmortemData struct {
+	Incident     *client.IncidentV2
+	Timeline     []timelineEvent
+	Updates      []client.IncidentUpdateV2
+	FollowUps    []client.FollowUpV2
+	Timestamps   []postmortemTimestamp
+	Duration     string
+	Roles        []postmortemRole
+	CustomFields []postmortemField
+	GeneratedAt  time.Time
+}
+
+type postmortemTimestamp struct {
+	Name  string
+	Value time.Time
+}
+
+type postmortemRole struct {
+	Role     string
+	Assignee string
+}
+
+type postmortemField struct {
+	Name  string
+	Value string
+}
+
+func (o *IncidentPostmortemOptions) Run(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) error {
+	location := time.Local
+	if o.utc {
+		location = time.UTC
+	}
+
+	// parse before fetching anything, so a broken template fails fast
+	tmpl, err := o.parseTemplate(location)
+	if err != nil {
+		return err
+	}
+
+	incident, err := FindIncident(ctx, logger, cl, o.incident)
+	if err != nil {
+		return fmt.Errorf("failed to find incident: %s", err)
+	}
+
+	updates, err := ListAllIncidentUpdates(ctx, logger, cl, incident.Id)
+	if err != nil {
+		return fmt.Errorf("failed to list incident updates: %s", err)
+	}
+
+	followUps, err := ListAllFollowUps(ctx, logger, cl, client.FollowUpsV2ListParams{IncidentId: &incident.Id})
+	if err != nil {
+		return fmt.Errorf("failed to list follow-ups: %s", err)
+	}
+
+	data := postmortemData{
+		Incident:    incident,
+		Timeline:    buildTimeline(incident, updates, followUps),
+		Updates:     updates,
+		FollowUps:   followUps,
+		GeneratedAt: time.Now(),
+	}
+
+	for _, timestamp := range lo.FromPtr(incident.IncidentTimestampValues) {
+		if timestamp.Value != nil && timestamp.Value.Value != nil {
+			data.Timestamps = append(data.Timestamps, postmortemTimestamp{Name: timestamp.IncidentTimestamp.Name, Value: *timestamp.Value.Value})
+		}
+	}
+
+	if resolved := incidentTimestamp(*incident, "Resolved at"); resolved != nil {
+		start := lo.FromPtrOr(incidentTimestamp(*incident, "Reported at"), incident.CreatedAt)
+		data.Duration = formatMinutes(resolved.Sub(start).Minutes())
+	}
+
+	for _, assignment := range incident.IncidentRoleAssignments {
+		if assignment.Assignee != nil {
+			data.Roles = append(data.Roles, postmortemRole{Role: assignment.Role.Name, Assignee: userLabel(assignment.Assignee)})
+		}
+	}
+
+	for _, entry := range incident.CustomFieldEntries {
+		if value := customFieldEntryValue(entry); value != "" {
+			data.CustomFields = append(data.CustomFields, postmortemField{Name: entry.CustomField.Name, Value: value})
+		}
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return fmt.Errorf("failed to render template: %s", err)
+	}
+
+	if _, err := os.Stdout.WriteString(b.String()); err != nil {
+		return errors.Wrap(err, "failed to write output")
+	}
+	return nil
+}
+
+func (o *IncidentPostmortemOptions) parseTemplate(location *time.Location) (*template.Template, error) {
+	text, name := defaultPostmortemTemplate, "postmortem.md.tmpl"
+	if o.template != "" {
+		data, err := os.ReadFile(o.template)
+		if err != nil {
+			return nil, errors.Wrap(err, "reading template")
+		}
+		text, name = string(data), o.template
+	}
+
+	funcs := template.FuncMap{
+		"time": func(v any) string {
+			const layout = "2006-01-02 15:04 MST"
+			switch t := v.(type) {
+			case time.Time:
+				return t.In(location).Format(layout)
+			case *time.Time:
+				if t != nil {
+					return t.In(location).Format(layout)
+				}
+			}
+			return ""
+		},
+		"user": userLabel,
+		"cell": func(s string) string {
+			return strings.ReplaceAll(strings.Join(strings.Fields(s), " "), "|", `\|`)
+		},
+		"join": strings.Join,
+	}
+
+	tmpl, err := template.New(name).Funcs(funcs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %s", err)
+	}
+	return tmpl, nil
+}
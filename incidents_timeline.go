@@ -0,0 +1,262 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alexeldeib/incli/client"
+	kitlog "github.com/go-kit/log"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const outputText = "text"
+
+func NewIncidentTimelineCommand() *cobra.Command {
+	opts := &IncidentTimelineOptions{}
+	cmd := &cobra.Command{
+		Use:   "timeline INC-123|ID",
+		Short: "show what happened during an incident, in order",
+		Long: `show what happened during an incident, in order.
+
+Merges the incident's status and severity updates, timestamps such as when it was
+reported or resolved, and the creation and completion of follow-ups. Role assignments
+carry no time in the API, so they are listed separately after the timeline.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			opts.incident = args[0]
+
+			ctx, logger, cl, err := setup()
+			if err != nil {
+				fmt.Printf("failed to setup: %s", err)
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger, cl); err != nil {
+				logger.Log("msg", "failed to run", "error", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.output, "output", "o", outputText, "output format, one of: text, json, jsonl")
+	cmd.Flags().BoolVar(&opts.utc, "utc", false, "print times in UTC rather than the local time zone")
+
+	return cmd
+}
+
+type IncidentTimelineOptions struct {
+	incident string
+	output   string
+	utc      bool
+}
+
+// timelineEvent is one entry of an incident timeline. Role assignments have no time.
+type timelineEvent struct {
+	Time    *time.Time `json:"time,omitempty"`
+	Kind    string     `json:"kind"`
+	Summary string     `json:"summary"`
+	Actor   string     `json:"actor,omitempty"`
+	Message string     `json:"message,omitempty"`
+}
+
+func (o *IncidentTimelineOptions) Run(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) error {
+	if err := validateOutput(o.output, outputText, outputJSON, outputJSONL); err != nil {
+		return err
+	}
+
+	incident, err := FindIncident(ctx, logger, cl, o.incident)
+	if err != nil {
+		return fmt.Errorf("failed to find incident: %s", err)
+	}
+
+	updates, err := ListAllIncidentUpdates(ctx, logger, cl, incident.Id)
+	if err != nil {
+		return fmt.Errorf("failed to list incident updates: %s", err)
+	}
+
+	followUps, err := ListAllFollowUps(ctx, logger, cl, client.FollowUpsV2ListParams{IncidentId: &incident.Id})
+	if err != nil {
+		return fmt.Errorf("failed to list follow-ups: %s", err)
+	}
+
+	events := buildTimeline(incident, updates, followUps)
+
+	if o.output == outputText {
+		location := time.Local
+		if o.utc {
+			location = time.UTC
+		}
+		return printTimeline(os.Stdout, incident, events, location)
+	}
+
+	w, err := newRecordWriter(o.output)
+	if err != nil {
+		return err
+	}
+
+	for _, event := range events {
+		if err := w.Write(event); err != nil {
+			return err
+		}
+	}
+
+	return w.Close()
+}
+
+// buildTimeline merges everything known about an incident into events ordered by
+// time, followed by the undated role assignments.
+func buildTimeline(incident *client.IncidentV2, updates []client.IncidentUpdateV2, followUps []client.FollowUpV2) []timelineEvent {
+	events := []timelineEvent{{
+		Time:    &incident.CreatedAt,
+		Kind:    "created",
+		Summary: fmt.Sprintf("incident declared: %s", incident.Name),
+		Actor:   actorLabel(incident.Creator),
+	}}
+
+	if incident.IncidentTimestampValues != nil {
+		for _, timestamp := range *incident.IncidentTimestampValues {
+			if timestamp.Value == nil || timestamp.Value.Value == nil {
+				continue
+			}
+			events = append(events, timelineEvent{
+				Time:    timestamp.Value.Value,
+				Kind:    "timestamp",
+				Summary: timestamp.IncidentTimestamp.Name,
+			})
+		}
+	}
+
+	// updates only carry the new status and severity, so compare with the previous one
+	// to describe what changed
+	sort.SliceStable(updates, func(i, j int) bool { return updates[i].CreatedAt.Before(updates[j].CreatedAt) })
+
+	var previousStatus, previousSeverity string
+	for _, update := range updates {
+		var changes []string
+		if status := update.NewIncidentStatus.Name; status != previousStatus {
+			changes = append(changes, describeChange("status", previousStatus, status))
+			previousStatus = status
+		}
+		if update.NewSeverity != nil && update.NewSeverity.Name != previousSeverity {
+			changes = append(changes, describeChange("severity", previousSeverity, update.NewSeverity.Name))
+			previousSeverity = update.NewSeverity.Name
+		}
+		if len(changes) == 0 {
+			changes = []string{"update"}
+		}
+
+		event := timelineEvent{
+			Time:    &update.CreatedAt,
+			Kind:    "update",
+			Summary: strings.Join(changes, ", "),
+			Actor:   actorLabel(update.Updater),
+		}
+		if update.Message != nil {
+			event.Message = *update.Message
+		}
+		events = append(events, event)
+	}
+
+	// follow-ups don't say who created or completed them, so the assignee is only
+	// mentioned, never shown as the actor
+	for _, followUp := range followUps {
+		var assigned string
+		if followUp.Assignee != nil {
+			assigned = fmt.Sprintf("assigned to %s", userLabel(followUp.Assignee))
+		}
+
+		events = append(events, timelineEvent{
+			Time:    &followUp.CreatedAt,
+			Kind:    "follow_up",
+			Summary: fmt.Sprintf("follow-up created: %s", followUp.Title),
+			Message: assigned,
+		})
+		if followUp.CompletedAt != nil {
+			events = append(events, timelineEvent{
+				Time:    followUp.CompletedAt,
+				Kind:    "follow_up_completed",
+				Summary: fmt.Sprintf("follow-up %s: %s", strings.ReplaceAll(string(followUp.Status), "_", " "), followUp.Title),
+				Message: assigned,
+			})
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Time.Before(*events[j].Time) })
+
+	for _, assignment := range incident.IncidentRoleAssignments {
+		if assignment.Assignee == nil {
+			continue
+		}
+		events = append(events, timelineEvent{
+			Kind:    "role",
+			Summary: assignment.Role.Name,
+			Actor:   userLabel(assignment.Assignee),
+		})
+	}
+
+	return events
+}
+
+func describeChange(field, from, to string) string {
+	if from == "" {
+		return fmt.Sprintf("%s %s", field, to)
+	}
+	return fmt.Sprintf("%s %s -> %s", field, from, to)
+}
+
+// actorLabel is who did something, a user or the name of an API key.
+func actorLabel(actor client.ActorV2) string {
+	switch {
+	case actor.User != nil:
+		return userLabel(actor.User)
+	case actor.ApiKey != nil:
+		return fmt.Sprintf("API key %s", actor.ApiKey.Name)
+	}
+	return ""
+}
+
+func printTimeline(out io.Writer, incident *client.IncidentV2, events []timelineEvent, location *time.Location) error {
+	const layout = "2006-01-02 15:04:05 MST"
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s\n\n", incident.Reference, incident.Name)
+
+	var roles []timelineEvent
+	for _, event := range events {
+		if event.Time == nil {
+			roles = append(roles, event)
+			continue
+		}
+
+		stamp := event.Time.In(location).Format(layout)
+		fmt.Fprintf(&b, "%s  %s", stamp, event.Summary)
+		if event.Actor != "" {
+			fmt.Fprintf(&b, " (%s)", event.Actor)
+		}
+		b.WriteString("\n")
+
+		for _, line := range strings.Split(strings.TrimSpace(event.Message), "\n") {
+			if line != "" {
+				fmt.Fprintf(&b, "%s  > %s\n", strings.Repeat(" ", len(stamp)), line)
+			}
+		}
+	}
+
+	if len(roles) > 0 {
+		b.WriteString("\nroles:\n")
+		for _, role := range roles {
+			fmt.Fprintf(&b, "  %s: %s\n", role.Summary, role.Actor)
+		}
+	}
+
+	if _, err := io.WriteString(out, b.String()); err != nil {
+		return errors.Wrap(err, "failed to write output")
+	}
+	return nil
+}
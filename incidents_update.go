@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/alexeldeib/incli/client"
+	kitlog "github.com/go-kit/log"
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+)
+
+// terminalStatusCategories are the categories an incident can't leave once it's in one.
+var terminalStatusCategories = []client.IncidentStatusV1Category{
+	client.IncidentStatusV1CategoryDeclined,
+	client.IncidentStatusV1CategoryMerged,
+	client.IncidentStatusV1CategoryCanceled,
+}
+
+func NewUpdateIncidentCommand() *cobra.Command {
+	opts := &UpdateIncidentOptions{}
+	cmd := &cobra.Command{
+		Use:   "update INC-123|ID",
+		Short: "change the severity of an incident",
+		Long: `change the severity of an incident.
+
+--status and --message are resolved and checked, e.g. that the incident may move to the
+status, but the API has no way to change an incident's status or to post an update
+message. When either is given nothing is changed, use /inc update in the incident
+channel instead.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			opts.incident = args[0]
+
+			ctx, logger, cl, err := setup()
+			if err != nil {
+				fmt.Printf("failed to setup: %s", err)
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger, cl); err != nil {
+				logger.Log("msg", "failed to run", "error", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.severity, "severity", "", "name or ID of the severity to change the incident to")
+	cmd.Flags().StringVar(&opts.status, "status", "", "name or ID of the status to move the incident to, not supported by the API")
+	cmd.Flags().StringVar(&opts.message, "message", "", "update message to post, not supported by the API")
+	cmd.Flags().BoolVar(&opts.notify, "notify", false, "announce the change in the incident's Slack channel")
+	cmd.Flags().StringVarP(&opts.output, "output", "o", outputText, "output format, one of: text, json")
+
+	return cmd
+}
+
+type UpdateIncidentOptions struct {
+	incident string
+	severity string
+	status   string
+	message  string
+	notify   bool
+	output   string
+}
+
+func (o *UpdateIncidentOptions) Run(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) error {
+	if err := validateOutput(o.output, outputText, outputJSON); err != nil {
+		return err
+	}
+
+	if o.severity == "" && o.status == "" && o.message == "" {
+		return fmt.Errorf("at least one of --severity, --status or --message must be specified")
+	}
+
+	if err := RequireRoles(ctx, logger, cl, client.IdentityV1RolesIncidentEditor); err != nil {
+		return err
+	}
+
+	incident, err := FindIncident(ctx, logger, cl, o.incident)
+	if err != nil {
+		return fmt.Errorf("failed to find incident: %s", err)
+	}
+
+	payload := client.IncidentEditPayloadV2{}
+	if o.severity != "" {
+		severity, err := FindSeverity(ctx, logger, cl, o.severity)
+		if err != nil {
+			return fmt.Errorf("failed to find severity: %s", err)
+		}
+		if incident.Severity == nil || incident.Severity.Id != severity.Id {
+			payload.SeverityId = &severity.Id
+		}
+	}
+
+	if o.status != "" {
+		status, err := FindIncidentStatus(ctx, logger, cl, o.status)
+		if err != nil {
+			return fmt.Errorf("failed to find incident status: %s", err)
+		}
+		if err := validateStatusTransition(incident.IncidentStatus, *status); err != nil {
+			return fmt.Errorf("can't move %s to status %q: %s", incident.Reference, status.Name, err)
+		}
+	}
+
+	if o.status != "" || o.message != "" {
+		return fmt.Errorf("the API can't change an incident's status or post an update message, nothing was changed: use /inc update in the incident channel instead")
+	}
+
+	if payload.SeverityId == nil {
+		logger.Log("msg", "incident already has this severity", "incident", incident.Reference, "severity", incident.Severity.Name)
+	} else {
+		incident, err = UpdateIncident(ctx, logger, cl, incident.Id, payload, o.notify)
+		if err != nil {
+			return fmt.Errorf("failed to update incident: %s", err)
+		}
+		logger.Log("msg", "updated incident", "incident", incident.Reference, "id", incident.Id)
+	}
+
+	if o.output == outputJSON {
+		if err := serialize(incident); err != nil {
+			return fmt.Errorf("failed to marshal json: %q", err)
+		}
+		return nil
+	}
+
+	severity := "none"
+	if incident.Severity != nil {
+		severity = incident.Severity.Name
+	}
+	fmt.Printf("%s %s: status %s, severity %s\n", incident.Reference, incident.Name, incident.IncidentStatus.Name, severity)
+
+	return nil
+}
+
+// validateStatusTransition checks an incident may move from one status to another.
+// Triage, declined, merged and canceled statuses are only reached through their own
+// workflows, and an incident never leaves the last three.
+func validateStatusTransition(from, to client.IncidentStatusV1) error {
+	if from.Id == to.Id {
+		return fmt.Errorf("it's already in that status")
+	}
+
+	if lo.Contains(terminalStatusCategories, from.Category) {
+		return fmt.Errorf("it was %s, so its status is final", from.Category)
+	}
+
+	if to.Category == client.IncidentStatusV1CategoryTriage || lo.Contains(terminalStatusCategories, to.Category) {
+		return fmt.Errorf("incidents can't be moved to a %s status", to.Category)
+	}
+
+	return nil
+}
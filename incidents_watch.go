@@ -0,0 +1,232 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alexeldeib/incli/client"
+	kitlog "github.com/go-kit/log"
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+)
+
+// incidentChange is one thing --watch saw change about an incident between two polls.
+type incidentChange struct {
+	Time       time.Time `json:"time"`
+	Kind       string    `json:"kind"`
+	IncidentID string    `json:"incident_id"`
+	Reference  string    `json:"reference"`
+	Name       string    `json:"name"`
+	Field      string    `json:"field,omitempty"`
+	From       string    `json:"from,omitempty"`
+	To         string    `json:"to,omitempty"`
+}
+
+// runWatch polls the incidents matching filter, which may be nil, printing what changed
+// since the previous poll until ctx is cancelled. The first poll only takes a snapshot.
+func (o *GetIncidentOptions) runWatch(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, filter *incidentFilter) error {
+	params := client.IncidentsV2ListParams{}
+	if filter != nil {
+		params = filter.params()
+	}
+	params.PageSize = &o.pageSize
+
+	poll := func() (map[string]client.IncidentV2, error) {
+		incidents := map[string]client.IncidentV2{}
+		err := WalkIncidents(ctx, logger, cl, params, func(incident client.IncidentV2) error {
+			if filter == nil || filter.matches(incident) {
+				incidents[incident.Id] = incident
+			}
+			return nil
+		})
+		return incidents, err
+	}
+
+	started := time.Now()
+	snapshot, err := poll()
+	if err != nil {
+		return fmt.Errorf("failed to list incidents: %s", err)
+	}
+	logger.Log("msg", "watching incidents", "count", len(snapshot), "interval", o.interval)
+
+	ticker := time.NewTicker(o.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		current, err := poll()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			logger.Log("msg", "failed to poll incidents, retrying at the next interval", "error", err)
+			continue
+		}
+
+		changes := []incidentChange{}
+		for id, incident := range current {
+			previous, ok := snapshot[id]
+			switch {
+			case ok:
+				changes = append(changes, diffIncident(previous, incident)...)
+			case incident.CreatedAt.After(started):
+				changes = append(changes, incidentChange{
+					Time:       incident.CreatedAt,
+					Kind:       "created",
+					IncidentID: incident.Id,
+					Reference:  incident.Reference,
+					Name:       incident.Name,
+				})
+			}
+		}
+
+		// an incident that stopped matching the filter most likely changed status, so
+		// fetch it once more to report how
+		for id, previous := range snapshot {
+			if _, ok := current[id]; ok {
+				continue
+			}
+			incident, err := ShowIncidentByID(ctx, logger, cl, id)
+			if err != nil {
+				if !client.IsNotFound(err) && ctx.Err() == nil {
+					logger.Log("msg", "failed to fetch incident that no longer matches", "incident", previous.Reference, "error", err)
+				}
+				continue
+			}
+			changes = append(changes, diffIncident(previous, *incident)...)
+		}
+
+		sort.SliceStable(changes, func(i, j int) bool { return changes[i].Time.Before(changes[j].Time) })
+		if err := o.writeChanges(changes); err != nil {
+			return err
+		}
+
+		snapshot = current
+	}
+}
+
+// diffIncident compares two copies of an incident, skipping the comparison when the
+// incident hasn't been updated in between.
+func diffIncident(before, after client.IncidentV2) []incidentChange {
+	if after.UpdatedAt.Equal(before.UpdatedAt) {
+		return nil
+	}
+
+	changes := []incidentChange{}
+	change := func(kind, field, from, to string) {
+		changes = append(changes, incidentChange{
+			Time:       after.UpdatedAt,
+			Kind:       kind,
+			IncidentID: after.Id,
+			Reference:  after.Reference,
+			Name:       after.Name,
+			Field:      field,
+			From:       from,
+			To:         to,
+		})
+	}
+
+	if before.IncidentStatus.Id != after.IncidentStatus.Id {
+		change("status_changed", "", before.IncidentStatus.Name, after.IncidentStatus.Name)
+	}
+
+	severityName := func(v *client.SeverityV2) string {
+		if v == nil {
+			return ""
+		}
+		return v.Name
+	}
+	if from, to := severityName(before.Severity), severityName(after.Severity); from != to {
+		change("severity_changed", "", from, to)
+	}
+
+	fromFields, toFields := customFieldValues(before), customFieldValues(after)
+	fields := lo.Uniq(append(lo.Keys(fromFields), lo.Keys(toFields)...))
+	sort.Strings(fields)
+	for _, field := range fields {
+		if fromFields[field] != toFields[field] {
+			change("custom_field_changed", field, fromFields[field], toFields[field])
+		}
+	}
+
+	return changes
+}
+
+// customFieldValues maps the name of every custom field set on an incident to its
+// values, joined into one string.
+func customFieldValues(incident client.IncidentV2) map[string]string {
+	values := map[string]string{}
+	for _, entry := range incident.CustomFieldEntries {
+		if value := customFieldEntryValue(entry); value != "" {
+			values[entry.CustomField.Name] = value
+		}
+	}
+	return values
+}
+
+// customFieldEntryValue renders the values of a custom field entry as one string.
+func customFieldEntryValue(entry client.CustomFieldEntryV1) string {
+	parts := lo.Map(entry.Values, func(v client.CustomFieldValueV1, _ int) string {
+		switch {
+		case v.ValueOption != nil:
+			return v.ValueOption.Value
+		case v.ValueCatalogEntry != nil:
+			return v.ValueCatalogEntry.Name
+		case v.ValueText != nil:
+			return *v.ValueText
+		case v.ValueLink != nil:
+			return *v.ValueLink
+		default:
+			return lo.FromPtr(v.ValueNumeric)
+		}
+	})
+	return strings.Join(parts, ", ")
+}
+
+func (o *GetIncidentOptions) writeChanges(changes []incidentChange) error {
+	if o.output == outputJSONL {
+		w, err := newRecordWriter(o.output)
+		if err != nil {
+			return err
+		}
+		for _, change := range changes {
+			if err := w.Write(change); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	const layout = "2006-01-02 15:04:05 MST"
+
+	var b strings.Builder
+	for _, change := range changes {
+		var summary string
+		switch change.Kind {
+		case "created":
+			summary = fmt.Sprintf("created: %s", change.Name)
+		case "status_changed":
+			summary = describeChange("status", change.From, change.To)
+		case "severity_changed":
+			summary = describeChange("severity", change.From, lo.Ternary(change.To != "", change.To, "(none)"))
+		default:
+			summary = describeChange(change.Field, change.From, lo.Ternary(change.To != "", change.To, "(cleared)"))
+		}
+		fmt.Fprintf(&b, "%s  %s %s\n", change.Time.In(time.Local).Format(layout), change.Reference, summary)
+	}
+
+	if _, err := io.WriteString(os.Stdout, b.String()); err != nil {
+		return errors.Wrap(err, "failed to write output")
+	}
+	return nil
+}
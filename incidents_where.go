@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/alexeldeib/incli/client"
+	kitlog "github.com/go-kit/log"
+	"github.com/samber/lo"
+)
+
+// incidentFilterKeys are what a --where condition can test.
+var incidentFilterKeys = []string{"status", "status_category", "severity", "incident_type", "mode"}
+
+var incidentStatusCategoriesAll = []client.IncidentStatusV1Category{
+	client.IncidentStatusV1CategoryTriage,
+	client.IncidentStatusV1CategoryLive,
+	client.IncidentStatusV1CategoryLearning,
+	client.IncidentStatusV1CategoryClosed,
+	client.IncidentStatusV1CategoryDeclined,
+	client.IncidentStatusV1CategoryMerged,
+	client.IncidentStatusV1CategoryCanceled,
+}
+
+// incidentFilter is a parsed --where expression such as
+// "status_category=closed,severity=Critical|Major". Every condition must hold, and a
+// condition holds when the incident has one of its values, or none of them with !=.
+type incidentFilter struct {
+	conditions []incidentCondition
+}
+
+type incidentCondition struct {
+	key    string
+	negate bool
+	values []string
+	// ids are the values as incidents carry them, e.g. severity IDs, set by resolve
+	ids []string
+}
+
+func parseIncidentFilter(expr string) (*incidentFilter, error) {
+	filter := &incidentFilter{}
+	for _, part := range strings.Split(expr, ",") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+
+		key, value, found := strings.Cut(part, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid condition %q, must be KEY=VALUE or KEY!=VALUE", part)
+		}
+
+		negate := strings.HasSuffix(key, "!")
+		key = strings.TrimSpace(strings.TrimSuffix(key, "!"))
+		if !lo.Contains(incidentFilterKeys, key) {
+			return nil, fmt.Errorf("invalid condition %q, key must be one of %v", part, incidentFilterKeys)
+		}
+		if lo.ContainsBy(filter.conditions, func(c incidentCondition) bool { return c.key == key }) {
+			return nil, fmt.Errorf("invalid condition %q, %s is already tested, use %s=a|b to allow several values", part, key, key)
+		}
+
+		values := lo.Map(strings.Split(value, "|"), func(v string, _ int) string { return strings.TrimSpace(v) })
+		if lo.Contains(values, "") {
+			return nil, fmt.Errorf("invalid condition %q, values must not be empty", part)
+		}
+
+		filter.conditions = append(filter.conditions, incidentCondition{key: key, negate: negate, values: values})
+	}
+
+	if len(filter.conditions) == 0 {
+		return nil, fmt.Errorf("no conditions in %q", expr)
+	}
+
+	return filter, nil
+}
+
+// resolve looks up the statuses, severities and incident types the conditions name.
+func (f *incidentFilter) resolve(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) error {
+	for i := range f.conditions {
+		c := &f.conditions[i]
+		c.ids = []string{}
+		for _, value := range c.values {
+			id, err := resolveIncidentFilterValue(ctx, logger, cl, c.key, value)
+			if err != nil {
+				return err
+			}
+			c.ids = append(c.ids, id)
+		}
+	}
+
+	return nil
+}
+
+func resolveIncidentFilterValue(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, key, value string) (string, error) {
+	switch key {
+	case "status":
+		status, err := FindIncidentStatus(ctx, logger, cl, value)
+		if err != nil {
+			return "", err
+		}
+		return status.Id, nil
+	case "severity":
+		severity, err := FindSeverity(ctx, logger, cl, value)
+		if err != nil {
+			return "", err
+		}
+		return severity.Id, nil
+	case "incident_type":
+		incidentType, err := FindIncidentType(ctx, logger, cl, value)
+		if err != nil {
+			return "", err
+		}
+		return incidentType.Id, nil
+	case "status_category":
+		category := client.IncidentStatusV1Category(strings.ToLower(value))
+		if !lo.Contains(incidentStatusCategoriesAll, category) {
+			return "", fmt.Errorf("status_category must be one of %v: %q", incidentStatusCategoriesAll, value)
+		}
+		return string(category), nil
+	default: // mode
+		mode := strings.ToLower(value)
+		if !lo.Contains(incidentModes, mode) {
+			return "", fmt.Errorf("mode must be one of %v: %q", incidentModes, value)
+		}
+		return mode, nil
+	}
+}
+
+// params has the API filter on the conditions it supports, saving pages of incidents
+// that would only be dropped. matches still has the final say on every incident.
+func (f *incidentFilter) params() client.IncidentsV2ListParams {
+	params := client.IncidentsV2ListParams{}
+	for _, c := range f.conditions {
+		condition := &map[string][]string{lo.Ternary(c.negate, "not_in", "one_of"): c.ids}
+		switch c.key {
+		case "status":
+			params.Status = condition
+		case "status_category":
+			params.StatusCategory = condition
+		case "severity":
+			params.Severity = condition
+		case "incident_type":
+			if !c.negate {
+				params.IncidentType = condition
+			}
+		}
+	}
+	return params
+}
+
+func (f *incidentFilter) matches(incident client.IncidentV2) bool {
+	for _, c := range f.conditions {
+		if lo.Contains(c.ids, incidentFilterValue(incident, c.key)) == c.negate {
+			return false
+		}
+	}
+	return true
+}
+
+func incidentFilterValue(incident client.IncidentV2, key string) string {
+	switch key {
+	case "status":
+		return incident.IncidentStatus.Id
+	case "status_category":
+		return string(incident.IncidentStatus.Category)
+	case "severity":
+		if incident.Severity == nil {
+			return ""
+		}
+		return incident.Severity.Id
+	case "incident_type":
+		if incident.IncidentType == nil {
+			return ""
+		}
+		return incident.IncidentType.Id
+	default: // mode
+		return string(incident.Mode)
+	}
+}
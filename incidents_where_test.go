@@ -0,0 +1,59 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseIncidentFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		want    []incidentCondition
+		wantErr bool
+	}{
+		{
+			name: "one condition",
+			expr: "status_category=live",
+			want: []incidentCondition{{key: "status_category", values: []string{"live"}}},
+		},
+		{
+			name: "several conditions and values",
+			expr: "status_category!=declined, severity = Critical | Major",
+			want: []incidentCondition{
+				{key: "status_category", negate: true, values: []string{"declined"}},
+				{key: "severity", values: []string{"Critical", "Major"}},
+			},
+		},
+		{
+			name: "values may contain equals signs",
+			expr: "incident_type=a=b",
+			want: []incidentCondition{{key: "incident_type", values: []string{"a=b"}}},
+		},
+		{
+			name: "empty conditions are skipped",
+			expr: "mode=standard,,",
+			want: []incidentCondition{{key: "mode", values: []string{"standard"}}},
+		},
+		{name: "no conditions", expr: " , ", wantErr: true},
+		{name: "no value", expr: "severity", wantErr: true},
+		{name: "unknown key", expr: "team=payments", wantErr: true},
+		{name: "empty value", expr: "severity=Major|", wantErr: true},
+		{name: "key repeated", expr: "severity=Major,severity!=Minor", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseIncidentFilter(tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseIncidentFilter(%q) error = %v, wantErr %v", tt.expr, err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(got.conditions, tt.want) {
+				t.Errorf("parseIncidentFilter(%q) = %+v, want %+v", tt.expr, got.conditions, tt.want)
+			}
+		})
+	}
+}
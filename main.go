@@ -24,6 +24,32 @@ func main() {
 }
 
 func setup() (context.Context, kitlog.Logger, *client.ClientWithResponses, error) {
+	ctx, logger := setupLocal()
+
+	apiKey := os.Getenv("INC_API_KEY")
+
+	if apiKey == "" {
+		return nil, nil, nil, fmt.Errorf("INC_API_KEY must be set")
+	}
+
+	cl, err := client.New(ctx, apiKey, "https://api.incident.io", "ace-cohere-cli")
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(err, "creating client")
+	}
+
+	if !noCache {
+		responseCache, err = newDiskCache(apiKey)
+		if err != nil {
+			logger.Log("msg", "failed to set up cache, continuing without it", "error", err)
+		}
+	}
+
+	return ctx, logger, cl, nil
+}
+
+// setupLocal is the part of setup for commands that never call the API, so don't need
+// an API key.
+func setupLocal() (context.Context, kitlog.Logger) {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	logger := kitlog.NewLogfmtLogger(kitlog.NewSyncWriter(os.Stderr))
@@ -42,27 +68,32 @@ func setup() (context.Context, kitlog.Logger, *client.ClientWithResponses, error
 		os.Exit(1)
 	}()
 
-	apiKey := os.Getenv("INC_API_KEY")
-
-	if apiKey == "" {
-		return nil, nil, nil, fmt.Errorf("INC_API_KEY must be set")
-	}
-
-	cl, err := client.New(ctx, apiKey, "https://api.incident.io", "ace-cohere-cli")
-	if err != nil {
-		return nil, nil, nil, errors.Wrap(err, "creating client")
-	}
-
-	return ctx, logger, cl, nil
+	return ctx, logger
 }
 
 func NewRootCommand() *cobra.Command {
 	root := &cobra.Command{
 		Use: "inc",
 	}
+	root.PersistentFlags().BoolVar(&noCache, "no-cache", false, "always fetch reference data from the API instead of the local cache")
+
 	root.AddCommand()
 	root.AddCommand(NewIncidentsCommand())
 	root.AddCommand(NewCatalogCommand())
+	root.AddCommand(NewCustomFieldsCommand())
+	root.AddCommand(NewSeveritiesCommand())
+	root.AddCommand(NewStatusesCommand())
+	root.AddCommand(NewIncidentTypesCommand())
+	root.AddCommand(NewRolesCommand())
+	root.AddCommand(NewFollowUpsCommand())
+	root.AddCommand(NewActionsCommand())
+	root.AddCommand(NewUsersCommand())
+	root.AddCommand(NewReportCommand())
+	root.AddCommand(NewWhoamiCommand())
+	root.AddCommand(NewWebhooksCommand())
+	root.AddCommand(NewSyncCommand())
+	root.AddCommand(NewQueryCommand())
+	root.AddCommand(NewCacheCommand())
 
 	return root
 }
@@ -82,6 +113,7 @@ func NewCatalogCommand() *cobra.Command {
 	root.AddCommand()
 	root.AddCommand(entries)
 	root.AddCommand(types)
+	root.AddCommand(NewCatalogGraphCommand())
 	entries.AddCommand(NewGetCatalogEntriesCommand())
 	types.AddCommand(NewGetCatalogTypesCommand())
 
@@ -91,11 +123,18 @@ func NewCatalogCommand() *cobra.Command {
 func NewIncidentsCommand() *cobra.Command {
 	root := &cobra.Command{
 		Use:     "incidents",
-		Aliases: []string{"inc"},
+		Aliases: []string{"incident", "inc"},
 	}
 	root.AddCommand()
 	root.AddCommand(NewGetIncidentCommand())
 	root.AddCommand(NewPatchIncidentsCommand())
+	root.AddCommand(NewIncidentTimelineCommand())
+	root.AddCommand(NewIncidentPostmortemCommand())
+	root.AddCommand(NewIncidentExportCommand())
+	root.AddCommand(NewUpdateIncidentCommand())
+	root.AddCommand(NewIncidentAttachmentsCommand())
+	root.AddCommand(NewAttachURLCommand())
+	root.AddCommand(NewIncidentMembersCommand())
 
 	return root
 }
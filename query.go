@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+
+	kitlog "github.com/go-kit/log"
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+)
+
+func NewQueryCommand() *cobra.Command {
+	opts := &QueryOptions{}
+	cmd := &cobra.Command{
+		Use:   "query SQL [ARG...]",
+		Short: "run SQL against the database written by inc sync",
+		Long: `run SQL against the database written by inc sync.
+
+The database is opened read only. Any further arguments are bound to the ? placeholders
+of the statement in order. Times are UTC text, so compare them with the same format or
+through SQLite's date and time functions, e.g.
+
+  inc query "SELECT severity_name, count(*) FROM incidents WHERE created_at >= date('now', '-30 days') GROUP BY 1"
+  inc query "SELECT i.reference, f.title FROM follow_ups f JOIN incidents i ON i.id = f.incident_id WHERE f.status = ?" outstanding
+  inc query "SELECT reference, json_extract(raw, '$.slack_channel_name') FROM incidents"`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			opts.query, opts.args = args[0], args[1:]
+
+			ctx, logger := setupLocal()
+
+			if err := opts.Run(ctx, logger); err != nil {
+				logger.Log("msg", "failed to run", "error", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.db, "db", "incidents.db", "path of the SQLite database")
+	cmd.Flags().StringVarP(&opts.output, "output", "o", outputTable, "output format, one of: table, csv, jsonl")
+
+	return cmd
+}
+
+type QueryOptions struct {
+	db     string
+	output string
+	query  string
+	args   []string
+}
+
+func (o *QueryOptions) Run(ctx context.Context, logger kitlog.Logger) error {
+	if err := validateOutput(o.output, outputTable, outputCSV, outputJSONL); err != nil {
+		return err
+	}
+
+	// opening a missing database would create an empty one
+	if _, err := os.Stat(o.db); err != nil {
+		return fmt.Errorf("failed to open database, run inc sync first: %s", err)
+	}
+
+	dsn, err := sqliteDSN(o.db, "ro")
+	if err != nil {
+		return err
+	}
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return errors.Wrap(err, "failed to open database")
+	}
+	defer db.Close()
+
+	rows, err := db.QueryContext(ctx, o.query, lo.ToAnySlice(o.args)...)
+	if err != nil {
+		return fmt.Errorf("failed to query: %s", err)
+	}
+	defer rows.Close()
+
+	names, err := rows.Columns()
+	if err != nil {
+		return errors.Wrap(err, "failed to read columns")
+	}
+
+	var results [][]any
+	for rows.Next() {
+		values := make([]any, len(names))
+		if err := rows.Scan(lo.Map(values, func(_ any, i int) any { return &values[i] })...); err != nil {
+			return errors.Wrap(err, "failed to read row")
+		}
+		for i, v := range values {
+			if b, ok := v.([]byte); ok {
+				values[i] = string(b)
+			}
+		}
+		results = append(results, values)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to query: %s", err)
+	}
+
+	logger.Log("msg", "queried", "rows", len(results))
+
+	if o.output == outputTable {
+		cells := lo.Map(results, func(values []any, _ int) []string {
+			return lo.Map(values, func(v any, _ int) string { return formatExportValue(v) })
+		})
+		return writeTable(names, cells)
+	}
+
+	// csv and jsonl are written as export writes them, which keeps jsonl's key order
+	columns := lo.Map(names, func(name string, _ int) exportColumn { return exportColumn{name: name} })
+	out := bufio.NewWriter(os.Stdout)
+	w := newExportWriter(out, o.output, columns)
+	if err := w.header(); err != nil {
+		return err
+	}
+	for _, values := range results {
+		if err := w.row(values); err != nil {
+			return err
+		}
+	}
+	if err := w.flush(); err != nil {
+		return err
+	}
+	return errors.Wrap(out.Flush(), "failed to write output")
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSQLiteDSN(t *testing.T) {
+	ctx := context.Background()
+
+	for _, name := range []string{"incidents.db", "x#y.db", "x?y.db", "x%20y.db", "with space.db", "file:x.db"} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, name)
+
+			db, err := openSyncDB(ctx, path)
+			if err != nil {
+				t.Fatalf("openSyncDB(%q) error = %v", path, err)
+			}
+			if _, err := db.ExecContext(ctx, `CREATE TABLE t (n INTEGER)`); err != nil {
+				t.Fatalf("creating table: %v", err)
+			}
+			db.Close()
+
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 1 || entries[0].Name() != name {
+				t.Fatalf("openSyncDB(%q) wrote %v, want only %s", path, entries, name)
+			}
+
+			dsn, err := sqliteDSN(path, "ro")
+			if err != nil {
+				t.Fatalf("sqliteDSN(%q) error = %v", path, err)
+			}
+			db, err = sql.Open("sqlite", dsn)
+			if err != nil {
+				t.Fatalf("sql.Open(%q) error = %v", dsn, err)
+			}
+			defer db.Close()
+
+			var count int
+			if err := db.QueryRowContext(ctx, `SELECT count(*) FROM t`).Scan(&count); err != nil {
+				t.Fatalf("querying %q: %v", dsn, err)
+			}
+			if _, err := db.ExecContext(ctx, `INSERT INTO t VALUES (1)`); err == nil {
+				t.Errorf("inserting through %q succeeded, want a read-only database", dsn)
+			}
+		})
+	}
+}
@@ -0,0 +1,344 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alexeldeib/incli/client"
+	kitlog "github.com/go-kit/log"
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+)
+
+// customFieldGroupPrefix selects grouping by a custom field, e.g. custom-field:Team.
+const customFieldGroupPrefix = "custom-field:"
+
+var reportGroupings = []string{"severity", "type", "status", customFieldGroupPrefix + "NAME"}
+
+func NewReportCommand() *cobra.Command {
+	root := &cobra.Command{
+		Use:     "report",
+		Aliases: []string{"reports"},
+		Short:   "summarise incidents over a period",
+	}
+
+	root.AddCommand(NewReportStatsCommand())
+	root.AddCommand(NewReportWorkloadCommand())
+
+	return root
+}
+
+func NewReportStatsCommand() *cobra.Command {
+	opts := &ReportStatsOptions{}
+	cmd := &cobra.Command{
+		Use:   "stats",
+		Short: "count incidents and measure how quickly they were acknowledged and resolved",
+		Long: `count incidents and measure how quickly they were acknowledged and resolved.
+
+Covers incidents created since --since, optionally narrowed by --where as for incident
+get. Time to acknowledge runs from the --started-at timestamp to the --acknowledged-at
+one and time to resolve from --started-at to --resolved-at, using the names of the
+incident timestamps configured in incident.io. An incident missing the start timestamp
+is measured from when it was created, one missing the end timestamp is left out of that
+measure. Workload is the sum of the time responders spent on the incidents.
+
+csv and json print durations and workload in minutes.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, logger, cl, err := setup()
+			if err != nil {
+				fmt.Printf("failed to setup: %s", err)
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger, cl); err != nil {
+				logger.Log("msg", "failed to run", "error", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.since, "since", "30d", "only incidents created since, a duration such as 30d, 2w or 12h, or a date such as 2024-01-31")
+	cmd.Flags().StringVar(&opts.where, "where", "", "only incidents matching these conditions, e.g. \"mode=standard,status_category!=declined\"")
+	cmd.Flags().StringVar(&opts.groupBy, "group-by", "", "group incidents by severity, type, status or custom-field:NAME")
+	cmd.Flags().StringVar(&opts.startedAt, "started-at", "Reported at", "name of the timestamp both measures start from")
+	cmd.Flags().StringVar(&opts.acknowledgedAt, "acknowledged-at", "Accepted at", "name of the timestamp an incident is acknowledged at")
+	cmd.Flags().StringVar(&opts.resolvedAt, "resolved-at", "Resolved at", "name of the timestamp an incident is resolved at")
+	cmd.Flags().StringVarP(&opts.output, "output", "o", outputTable, "output format, one of: table, csv, json")
+
+	return cmd
+}
+
+type ReportStatsOptions struct {
+	since          string
+	where          string
+	groupBy        string
+	startedAt      string
+	acknowledgedAt string
+	resolvedAt     string
+	output         string
+}
+
+// incidentStats summarises a group of incidents. Durations are nil when no incident of
+// the group has the timestamps to measure them.
+type incidentStats struct {
+	Group                          string   `json:"group"`
+	Incidents                      int      `json:"incidents"`
+	Acknowledged                   int      `json:"acknowledged"`
+	MeanTimeToAcknowledgeMinutes   *float64 `json:"mean_time_to_acknowledge_minutes"`
+	MedianTimeToAcknowledgeMinutes *float64 `json:"median_time_to_acknowledge_minutes"`
+	Resolved                       int      `json:"resolved"`
+	MeanTimeToResolveMinutes       *float64 `json:"mean_time_to_resolve_minutes"`
+	MedianTimeToResolveMinutes     *float64 `json:"median_time_to_resolve_minutes"`
+	WorkloadMinutesTotal           float64  `json:"workload_minutes_total"`
+	WorkloadMinutesWorking         float64  `json:"workload_minutes_working"`
+	WorkloadMinutesLate            float64  `json:"workload_minutes_late"`
+	WorkloadMinutesSleeping        float64  `json:"workload_minutes_sleeping"`
+}
+
+func (o *ReportStatsOptions) Run(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) error {
+	if err := validateOutput(o.output, outputTable, outputCSV, outputJSON); err != nil {
+		return err
+	}
+
+	since, err := parseSince(o.since, time.Now())
+	if err != nil {
+		return fmt.Errorf("invalid --since: %s", err)
+	}
+
+	groupOf, err := incidentGrouping(ctx, logger, cl, o.groupBy)
+	if err != nil {
+		return fmt.Errorf("invalid --group-by: %s", err)
+	}
+
+	incidents, err := listIncidentsSince(ctx, logger, cl, since, o.where)
+	if err != nil {
+		return err
+	}
+
+	groups := map[string][]client.IncidentV2{}
+	for _, incident := range incidents {
+		group := groupOf(incident)
+		groups[group] = append(groups[group], incident)
+	}
+
+	stats := lo.MapToSlice(groups, func(group string, incidents []client.IncidentV2) incidentStats {
+		return o.summarise(group, incidents)
+	})
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Incidents != stats[j].Incidents {
+			return stats[i].Incidents > stats[j].Incidents
+		}
+		return stats[i].Group < stats[j].Group
+	})
+	if o.groupBy != "" && len(incidents) > 0 {
+		stats = append(stats, o.summarise("(total)", incidents))
+	}
+
+	logger.Log("msg", "summarised incidents", "incidents", len(incidents), "since", since.Format(time.RFC3339))
+
+	if o.output == outputJSON {
+		if err := serialize(stats); err != nil {
+			return fmt.Errorf("failed to marshal json: %q", err)
+		}
+		return nil
+	}
+
+	headers := []string{"group", "incidents", "acknowledged", "mean tta", "median tta", "resolved", "mean ttr", "median ttr", "workload", "working", "late", "sleeping"}
+	if o.output == outputCSV {
+		headers = []string{"group", "incidents", "acknowledged", "mean_tta_minutes", "median_tta_minutes", "resolved", "mean_ttr_minutes", "median_ttr_minutes", "workload_minutes", "working_minutes", "late_minutes", "sleeping_minutes"}
+	}
+
+	rows := lo.Map(stats, func(v incidentStats, _ int) []string {
+		minutes := formatMinutes
+		if o.output == outputCSV {
+			minutes = func(m float64) string { return strconv.FormatFloat(m, 'f', 1, 64) }
+		}
+		optional := func(m *float64) string {
+			if m == nil {
+				return lo.Ternary(o.output == outputCSV, "", "-")
+			}
+			return minutes(*m)
+		}
+		return []string{
+			v.Group, strconv.Itoa(v.Incidents),
+			strconv.Itoa(v.Acknowledged), optional(v.MeanTimeToAcknowledgeMinutes), optional(v.MedianTimeToAcknowledgeMinutes),
+			strconv.Itoa(v.Resolved), optional(v.MeanTimeToResolveMinutes), optional(v.MedianTimeToResolveMinutes),
+			minutes(v.WorkloadMinutesTotal), minutes(v.WorkloadMinutesWorking), minutes(v.WorkloadMinutesLate), minutes(v.WorkloadMinutesSleeping),
+		}
+	})
+
+	if o.output == outputCSV {
+		return writeCSV(headers, rows)
+	}
+	return writeTable(headers, rows)
+}
+
+func (o *ReportStatsOptions) summarise(group string, incidents []client.IncidentV2) incidentStats {
+	stats := incidentStats{Group: group, Incidents: len(incidents)}
+
+	var toAcknowledge, toResolve []float64
+	for _, incident := range incidents {
+		start := lo.FromPtrOr(incidentTimestamp(incident, o.startedAt), incident.CreatedAt)
+		if acknowledged := incidentTimestamp(incident, o.acknowledgedAt); acknowledged != nil {
+			toAcknowledge = append(toAcknowledge, acknowledged.Sub(start).Minutes())
+		}
+		if resolved := incidentTimestamp(incident, o.resolvedAt); resolved != nil {
+			toResolve = append(toResolve, resolved.Sub(start).Minutes())
+		}
+
+		stats.WorkloadMinutesTotal += lo.FromPtr(incident.WorkloadMinutesTotal)
+		stats.WorkloadMinutesWorking += lo.FromPtr(incident.WorkloadMinutesWorking)
+		stats.WorkloadMinutesLate += lo.FromPtr(incident.WorkloadMinutesLate)
+		stats.WorkloadMinutesSleeping += lo.FromPtr(incident.WorkloadMinutesSleeping)
+	}
+
+	stats.Acknowledged, stats.MeanTimeToAcknowledgeMinutes, stats.MedianTimeToAcknowledgeMinutes = len(toAcknowledge), mean(toAcknowledge), median(toAcknowledge)
+	stats.Resolved, stats.MeanTimeToResolveMinutes, stats.MedianTimeToResolveMinutes = len(toResolve), mean(toResolve), median(toResolve)
+
+	return stats
+}
+
+// listIncidentsSince returns the incidents created since a time that match where, if
+// it's set. The API can't filter by creation time, so every incident is listed.
+func listIncidentsSince(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, since time.Time, where string) ([]client.IncidentV2, error) {
+	var filter *incidentFilter
+	params := client.IncidentsV2ListParams{}
+	if where != "" {
+		var err error
+		if filter, err = parseIncidentFilter(where); err != nil {
+			return nil, fmt.Errorf("invalid --where: %s", err)
+		}
+		if err := filter.resolve(ctx, logger, cl); err != nil {
+			return nil, fmt.Errorf("invalid --where: %s", err)
+		}
+		params = filter.params()
+	}
+
+	incidents := []client.IncidentV2{}
+	err := WalkIncidents(ctx, logger, cl, params, func(incident client.IncidentV2) error {
+		if incident.CreatedAt.Before(since) || (filter != nil && !filter.matches(incident)) {
+			return nil
+		}
+		incidents = append(incidents, incident)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list incidents: %s", err)
+	}
+
+	return incidents, nil
+}
+
+// incidentGrouping returns what puts an incident in its group for a --group-by value.
+func incidentGrouping(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses, groupBy string) (func(client.IncidentV2) string, error) {
+	switch {
+	case groupBy == "":
+		return func(client.IncidentV2) string { return "(all)" }, nil
+	case groupBy == "severity":
+		return func(incident client.IncidentV2) string {
+			if incident.Severity == nil {
+				return "(none)"
+			}
+			return incident.Severity.Name
+		}, nil
+	case groupBy == "type":
+		return func(incident client.IncidentV2) string {
+			if incident.IncidentType == nil {
+				return "(none)"
+			}
+			return incident.IncidentType.Name
+		}, nil
+	case groupBy == "status":
+		return func(incident client.IncidentV2) string { return incident.IncidentStatus.Name }, nil
+	case strings.HasPrefix(groupBy, customFieldGroupPrefix):
+		field, err := FindCustomField(ctx, logger, cl, strings.TrimPrefix(groupBy, customFieldGroupPrefix))
+		if err != nil {
+			return nil, err
+		}
+		return func(incident client.IncidentV2) string {
+			if value, ok := customFieldValues(incident)[field.Name]; ok {
+				return value
+			}
+			return "(none)"
+		}, nil
+	}
+
+	return nil, fmt.Errorf("must be one of %v: %q", reportGroupings, groupBy)
+}
+
+// incidentTimestamp finds the value of the incident timestamp with a name, ignoring
+// case, or nil if it isn't set.
+func incidentTimestamp(incident client.IncidentV2, name string) *time.Time {
+	for _, timestamp := range lo.FromPtr(incident.IncidentTimestampValues) {
+		if strings.EqualFold(timestamp.IncidentTimestamp.Name, name) && timestamp.Value != nil {
+			return timestamp.Value.Value
+		}
+	}
+	return nil
+}
+
+// parseSince accepts a duration before now, which may be in days or weeks such as 30d
+// or 2w, or a date or RFC 3339 time.
+func parseSince(value string, now time.Time) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, err := strconv.Atoi(strings.TrimSuffix(value, suffix)); err == nil && strings.HasSuffix(value, suffix) && n >= 0 {
+			return now.Add(-time.Duration(n) * unit), nil
+		}
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return time.Time{}, fmt.Errorf("must be a duration such as 30d, 2w or 12h, or a date such as 2024-01-31: %q", value)
+	}
+	return now.Add(-d), nil
+}
+
+// formatMinutes renders minutes the way a person would say them, e.g. 2d3h or 45m.
+func formatMinutes(minutes float64) string {
+	d := time.Duration(minutes * float64(time.Minute)).Round(time.Minute)
+	if d < 0 {
+		return "-" + formatMinutes(-minutes)
+	}
+
+	days, hours, mins := int(d/(24*time.Hour)), int(d%(24*time.Hour)/time.Hour), int(d%time.Hour/time.Minute)
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd%dh", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%dh%dm", hours, mins)
+	}
+	return fmt.Sprintf("%dm", mins)
+}
+
+func mean(values []float64) *float64 {
+	if len(values) == 0 {
+		return nil
+	}
+	return lo.ToPtr(lo.Sum(values) / float64(len(values)))
+}
+
+func median(values []float64) *float64 {
+	if len(values) == 0 {
+		return nil
+	}
+
+	sorted := append([]float64{}, values...)
+	sort.Float64s(sorted)
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		return &sorted[middle]
+	}
+	return lo.ToPtr((sorted[middle-1] + sorted[middle]) / 2)
+}
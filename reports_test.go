@@ -0,0 +1,42 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{value: "30d", want: now.AddDate(0, 0, -30)},
+		{value: "0d", want: now},
+		{value: "2w", want: now.AddDate(0, 0, -14)},
+		{value: "12h", want: now.Add(-12 * time.Hour)},
+		{value: "90m", want: now.Add(-90 * time.Minute)},
+		{value: "2024-01-31", want: time.Date(2024, 1, 31, 0, 0, 0, 0, time.Local)},
+		{value: "2024-01-31T09:30:00Z", want: time.Date(2024, 1, 31, 9, 30, 0, 0, time.UTC)},
+		{value: "-1d", wantErr: true},
+		{value: "-12h", wantErr: true},
+		{value: "d", wantErr: true},
+		{value: "2024-13-01", wantErr: true},
+		{value: "yesterday", wantErr: true},
+		{value: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseSince(tt.value, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSince(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("parseSince(%q) = %s, want %s", tt.value, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/alexeldeib/incli/client"
+	kitlog "github.com/go-kit/log"
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+)
+
+// workloadKinds are what --threshold-on can compare against the threshold.
+var workloadKinds = []string{"total", "working", "late", "sleeping", "out-of-hours"}
+
+func NewReportWorkloadCommand() *cobra.Command {
+	opts := &ReportWorkloadOptions{}
+	cmd := &cobra.Command{
+		Use:   "workload",
+		Short: "attribute incident workload to the responders who carried it",
+		Long: `attribute incident workload to the responders who carried it.
+
+incident.io measures workload per incident, split into working hours, late and
+sleeping. Each incident's workload is shared equally between the distinct users
+assigned one of its roles, and incidents without any assignee are counted as
+(unassigned). Incident members aren't credited, as the API has no way to list them;
+only role assignees are.
+
+--threshold flags responders whose workload over the period exceeds it, comparing the
+kind of hours --threshold-on names. out-of-hours is late and sleeping together.
+
+csv and json print workload in minutes.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, logger, cl, err := setup()
+			if err != nil {
+				fmt.Printf("failed to setup: %s", err)
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger, cl); err != nil {
+				logger.Log("msg", "failed to run", "error", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.since, "since", "30d", "only incidents created since, a duration such as 30d, 2w or 12h, or a date such as 2024-01-31")
+	cmd.Flags().StringVar(&opts.where, "where", "", "only incidents matching these conditions, e.g. \"mode=standard,status_category!=declined\"")
+	cmd.Flags().DurationVar(&opts.threshold, "threshold", 0, "flag responders with more workload than this, e.g. 20h. 0 flags nobody")
+	cmd.Flags().StringVar(&opts.thresholdOn, "threshold-on", "total", "workload --threshold applies to, one of: total, working, late, sleeping, out-of-hours")
+	cmd.Flags().StringVarP(&opts.output, "output", "o", outputTable, "output format, one of: table, csv, json")
+
+	return cmd
+}
+
+type ReportWorkloadOptions struct {
+	since       string
+	where       string
+	threshold   time.Duration
+	thresholdOn string
+	output      string
+}
+
+// responderWorkload is the share of incident workload credited to one responder.
+type responderWorkload struct {
+	UserID                  string  `json:"user_id,omitempty"`
+	User                    string  `json:"user"`
+	Incidents               int     `json:"incidents"`
+	WorkloadMinutesTotal    float64 `json:"workload_minutes_total"`
+	WorkloadMinutesWorking  float64 `json:"workload_minutes_working"`
+	WorkloadMinutesLate     float64 `json:"workload_minutes_late"`
+	WorkloadMinutesSleeping float64 `json:"workload_minutes_sleeping"`
+	OverThreshold           bool    `json:"over_threshold"`
+}
+
+func (v responderWorkload) minutes(kind string) float64 {
+	switch kind {
+	case "working":
+		return v.WorkloadMinutesWorking
+	case "late":
+		return v.WorkloadMinutesLate
+	case "sleeping":
+		return v.WorkloadMinutesSleeping
+	case "out-of-hours":
+		return v.WorkloadMinutesLate + v.WorkloadMinutesSleeping
+	}
+	return v.WorkloadMinutesTotal
+}
+
+func (o *ReportWorkloadOptions) Run(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) error {
+	if err := validateOutput(o.output, outputTable, outputCSV, outputJSON); err != nil {
+		return err
+	}
+
+	if !lo.Contains(workloadKinds, o.thresholdOn) {
+		return fmt.Errorf("--threshold-on must be one of %v: %q", workloadKinds, o.thresholdOn)
+	}
+
+	if o.threshold < 0 {
+		return fmt.Errorf("--threshold must not be negative: %s", o.threshold)
+	}
+
+	since, err := parseSince(o.since, time.Now())
+	if err != nil {
+		return fmt.Errorf("invalid --since: %s", err)
+	}
+
+	incidents, err := listIncidentsSince(ctx, logger, cl, since, o.where)
+	if err != nil {
+		return err
+	}
+
+	workloads := attributeWorkload(incidents)
+
+	over := 0
+	for i := range workloads {
+		if o.threshold > 0 && workloads[i].minutes(o.thresholdOn) > o.threshold.Minutes() {
+			workloads[i].OverThreshold = true
+			over++
+		}
+	}
+	sort.Slice(workloads, func(i, j int) bool {
+		if a, b := workloads[i].minutes(o.thresholdOn), workloads[j].minutes(o.thresholdOn); a != b {
+			return a > b
+		}
+		return workloads[i].User < workloads[j].User
+	})
+
+	logger.Log("msg", "attributed workload", "incidents", len(incidents), "responders", len(workloads), "over_threshold", over, "since", since.Format(time.RFC3339))
+
+	if o.output == outputJSON {
+		if err := serialize(workloads); err != nil {
+			return fmt.Errorf("failed to marshal json: %q", err)
+		}
+		return nil
+	}
+
+	if o.output == outputCSV {
+		rows := lo.Map(workloads, func(v responderWorkload, _ int) []string {
+			minutes := func(m float64) string { return strconv.FormatFloat(m, 'f', 1, 64) }
+			return []string{v.UserID, v.User, strconv.Itoa(v.Incidents), minutes(v.WorkloadMinutesTotal), minutes(v.WorkloadMinutesWorking), minutes(v.WorkloadMinutesLate), minutes(v.WorkloadMinutesSleeping), strconv.FormatBool(v.OverThreshold)}
+		})
+		return writeCSV([]string{"user_id", "user", "incidents", "workload_minutes", "working_minutes", "late_minutes", "sleeping_minutes", "over_threshold"}, rows)
+	}
+
+	rows := lo.Map(workloads, func(v responderWorkload, _ int) []string {
+		return []string{v.User, strconv.Itoa(v.Incidents), formatMinutes(v.WorkloadMinutesTotal), formatMinutes(v.WorkloadMinutesWorking), formatMinutes(v.WorkloadMinutesLate), formatMinutes(v.WorkloadMinutesSleeping), lo.Ternary(v.OverThreshold, "yes", "")}
+	})
+	return writeTable([]string{"user", "incidents", "workload", "working", "late", "sleeping", "over threshold"}, rows)
+}
+
+// attributeWorkload shares the workload of every incident equally between the distinct
+// users assigned its roles.
+func attributeWorkload(incidents []client.IncidentV2) []responderWorkload {
+	byUser := map[string]*responderWorkload{}
+	credit := func(key string, user *client.UserV1, incident client.IncidentV2, share float64) {
+		w, ok := byUser[key]
+		if !ok {
+			w = &responderWorkload{User: "(unassigned)"}
+			if user != nil {
+				w.UserID, w.User = user.Id, userLabel(user)
+			}
+			byUser[key] = w
+		}
+		w.Incidents++
+		w.WorkloadMinutesTotal += lo.FromPtr(incident.WorkloadMinutesTotal) * share
+		w.WorkloadMinutesWorking += lo.FromPtr(incident.WorkloadMinutesWorking) * share
+		w.WorkloadMinutesLate += lo.FromPtr(incident.WorkloadMinutesLate) * share
+		w.WorkloadMinutesSleeping += lo.FromPtr(incident.WorkloadMinutesSleeping) * share
+	}
+
+	for _, incident := range incidents {
+		assignees := lo.UniqBy(
+			lo.FilterMap(incident.IncidentRoleAssignments, func(v client.IncidentRoleAssignmentV1, _ int) (*client.UserV1, bool) {
+				return v.Assignee, v.Assignee != nil
+			}),
+			func(v *client.UserV1) string { return v.Id },
+		)
+
+		if len(assignees) == 0 {
+			credit("", nil, incident, 1)
+			continue
+		}
+		for _, assignee := range assignees {
+			credit(assignee.Id, assignee, incident, 1/float64(len(assignees)))
+		}
+	}
+
+	return lo.MapToSlice(byUser, func(_ string, v *responderWorkload) responderWorkload { return *v })
+}
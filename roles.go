@@ -0,0 +1,488 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/alexeldeib/incli/client"
+	kitlog "github.com/go-kit/log"
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+func NewRolesCommand() *cobra.Command {
+	root := &cobra.Command{
+		Use:     "roles",
+		Aliases: []string{"role"},
+		Short:   "manage incident role definitions",
+	}
+
+	root.AddCommand(NewGetRolesCommand())
+	root.AddCommand(NewCreateRoleCommand())
+	root.AddCommand(NewUpdateRoleCommand())
+	root.AddCommand(NewDeleteRoleCommand())
+	root.AddCommand(NewApplyRolesCommand())
+
+	return root
+}
+
+func NewGetRolesCommand() *cobra.Command {
+	opts := &GetRolesOptions{}
+	cmd := &cobra.Command{
+		Use:   "get [NAME|SHORTFORM|ID]",
+		Short: "get one or all incident roles",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) > 0 {
+				opts.role = args[0]
+			}
+
+			ctx, logger, cl, err := setup()
+			if err != nil {
+				fmt.Printf("failed to setup: %s", err)
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger, cl); err != nil {
+				logger.Log("msg", "failed to run", "error", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.output, "output", "o", outputJSON, "output format, one of: json, jsonl, table")
+
+	return cmd
+}
+
+type GetRolesOptions struct {
+	role   string
+	output string
+}
+
+func (o *GetRolesOptions) Run(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) error {
+	if err := validateOutput(o.output, outputJSON, outputJSONL, outputTable); err != nil {
+		return err
+	}
+
+	var roles []client.IncidentRoleV2
+	if o.role != "" {
+		role, err := FindIncidentRole(ctx, logger, cl, o.role)
+		if err != nil {
+			return fmt.Errorf("failed to find incident role: %s", err)
+		}
+
+		if o.output == outputJSON {
+			if err := serialize(role); err != nil {
+				return fmt.Errorf("failed to marshal json: %q", err)
+			}
+			return nil
+		}
+		roles = []client.IncidentRoleV2{*role}
+	} else {
+		res, err := ListAllIncidentRoles(ctx, logger, cl)
+		if err != nil {
+			return fmt.Errorf("failed to list incident roles: %s", err)
+		}
+		roles = res
+	}
+
+	if o.output == outputTable {
+		rows := lo.Map(roles, func(v client.IncidentRoleV2, _ int) []string {
+			return []string{v.Id, v.Name, v.Shortform, string(v.RoleType), v.Description}
+		})
+		return writeTable([]string{"id", "name", "shortform", "type", "description"}, rows)
+	}
+
+	w, err := newRecordWriter(o.output)
+	if err != nil {
+		return err
+	}
+
+	for _, role := range roles {
+		if err := w.Write(role); err != nil {
+			return err
+		}
+	}
+
+	return w.Close()
+}
+
+func NewCreateRoleCommand() *cobra.Command {
+	opts := &CreateRoleOptions{}
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "create an incident role",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, logger, cl, err := setup()
+			if err != nil {
+				fmt.Printf("failed to setup: %s", err)
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger, cl); err != nil {
+				logger.Log("msg", "failed to run", "error", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.role.Name, "name", "", "name of the role, e.g. Communications Lead")
+	cmd.Flags().StringVar(&opts.role.Shortform, "shortform", "", "short name used in Slack commands, e.g. comms")
+	cmd.Flags().StringVar(&opts.role.Description, "description", "", "what the role is responsible for")
+	cmd.Flags().StringVar(&opts.role.Instructions, "instructions", "", "instructions shown to whoever is assigned the role")
+
+	return cmd
+}
+
+type CreateRoleOptions struct {
+	role roleDefinition
+}
+
+func (o *CreateRoleOptions) Run(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) error {
+	if err := o.role.validate(); err != nil {
+		return err
+	}
+
+	if err := RequireRoles(ctx, logger, cl, client.IdentityV1RolesManageSettings); err != nil {
+		return err
+	}
+
+	res, err := CreateIncidentRole(ctx, logger, cl, client.IncidentRolesV2CreateJSONRequestBody(o.role))
+	if err != nil {
+		return fmt.Errorf("failed to create incident role: %s", err)
+	}
+
+	if err := serialize(res); err != nil {
+		return fmt.Errorf("failed to marshal json: %q", err)
+	}
+
+	return nil
+}
+
+func NewUpdateRoleCommand() *cobra.Command {
+	opts := &UpdateRoleOptions{}
+	cmd := &cobra.Command{
+		Use:   "update NAME|SHORTFORM|ID",
+		Short: "change the name, shortform, description or instructions of an incident role",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			opts.role = args[0]
+			opts.changed = map[string]bool{}
+			for _, name := range []string{"name", "shortform", "description", "instructions"} {
+				opts.changed[name] = cmd.Flags().Changed(name)
+			}
+
+			ctx, logger, cl, err := setup()
+			if err != nil {
+				fmt.Printf("failed to setup: %s", err)
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger, cl); err != nil {
+				logger.Log("msg", "failed to run", "error", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.update.Name, "name", "", "new name of the role, unchanged if not given")
+	cmd.Flags().StringVar(&opts.update.Shortform, "shortform", "", "new shortform of the role, unchanged if not given")
+	cmd.Flags().StringVar(&opts.update.Description, "description", "", "new description of the role, unchanged if not given")
+	cmd.Flags().StringVar(&opts.update.Instructions, "instructions", "", "new instructions for the role, unchanged if not given")
+
+	return cmd
+}
+
+type UpdateRoleOptions struct {
+	role    string
+	update  roleDefinition
+	changed map[string]bool
+}
+
+func (o *UpdateRoleOptions) Run(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) error {
+	if err := RequireRoles(ctx, logger, cl, client.IdentityV1RolesManageSettings); err != nil {
+		return err
+	}
+
+	role, err := FindIncidentRole(ctx, logger, cl, o.role)
+	if err != nil {
+		return fmt.Errorf("failed to find incident role: %s", err)
+	}
+
+	// the API replaces every field, so carry over whatever isn't being changed
+	desired := roleDefinition{
+		Name:         lo.Ternary(o.changed["name"], o.update.Name, role.Name),
+		Shortform:    lo.Ternary(o.changed["shortform"], o.update.Shortform, role.Shortform),
+		Description:  lo.Ternary(o.changed["description"], o.update.Description, role.Description),
+		Instructions: lo.Ternary(o.changed["instructions"], o.update.Instructions, role.Instructions),
+	}
+	if err := desired.validate(); err != nil {
+		return err
+	}
+
+	res, err := UpdateIncidentRole(ctx, logger, cl, role.Id, client.IncidentRolesV2UpdateJSONRequestBody(desired))
+	if err != nil {
+		return fmt.Errorf("failed to update incident role: %s", err)
+	}
+
+	if err := serialize(res); err != nil {
+		return fmt.Errorf("failed to marshal json: %q", err)
+	}
+
+	return nil
+}
+
+func NewDeleteRoleCommand() *cobra.Command {
+	opts := &DeleteRoleOptions{}
+	cmd := &cobra.Command{
+		Use:   "delete NAME|SHORTFORM|ID",
+		Short: "delete a custom incident role",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			opts.role = args[0]
+
+			ctx, logger, cl, err := setup()
+			if err != nil {
+				fmt.Printf("failed to setup: %s", err)
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger, cl); err != nil {
+				logger.Log("msg", "failed to run", "error", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.yes, "yes", false, "confirm the deletion")
+
+	return cmd
+}
+
+type DeleteRoleOptions struct {
+	role string
+	yes  bool
+}
+
+func (o *DeleteRoleOptions) Run(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) error {
+	if err := RequireRoles(ctx, logger, cl, client.IdentityV1RolesManageSettings); err != nil {
+		return err
+	}
+
+	role, err := FindIncidentRole(ctx, logger, cl, o.role)
+	if err != nil {
+		return fmt.Errorf("failed to find incident role: %s", err)
+	}
+
+	if role.RoleType != client.IncidentRoleV2RoleTypeCustom {
+		return fmt.Errorf("incident role %q is built in and can't be deleted", role.Name)
+	}
+
+	if !o.yes {
+		return fmt.Errorf("deleting incident role %q unassigns it on every incident, pass --yes to confirm", role.Name)
+	}
+
+	if err := DeleteIncidentRole(ctx, logger, cl, role.Id); err != nil {
+		return fmt.Errorf("failed to delete incident role: %s", err)
+	}
+
+	logger.Log("msg", "deleted incident role", "name", role.Name, "id", role.Id)
+	return nil
+}
+
+// roleDefinition is an incident role as written in a roles file, and the fields the API
+// accepts when creating or updating one.
+type roleDefinition struct {
+	Description  string `yaml:"description"`
+	Instructions string `yaml:"instructions"`
+	Name         string `yaml:"name"`
+	Shortform    string `yaml:"shortform"`
+}
+
+func (r roleDefinition) validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("role name is required")
+	}
+	if r.Shortform == "" {
+		return fmt.Errorf("role %q needs a shortform", r.Name)
+	}
+	return nil
+}
+
+// differences lists the fields of role that don't match the definition.
+func (r roleDefinition) differences(role client.IncidentRoleV2) []string {
+	var fields []string
+	if r.Name != role.Name {
+		fields = append(fields, "name")
+	}
+	if r.Shortform != role.Shortform {
+		fields = append(fields, "shortform")
+	}
+	if r.Description != role.Description {
+		fields = append(fields, "description")
+	}
+	if r.Instructions != role.Instructions {
+		fields = append(fields, "instructions")
+	}
+	return fields
+}
+
+type rolesFile struct {
+	Roles []roleDefinition `yaml:"roles"`
+}
+
+func readRolesFile(path string) ([]roleDefinition, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read roles file: %s", err)
+	}
+	defer f.Close()
+
+	var file rolesFile
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	if err := dec.Decode(&file); err != nil {
+		return nil, fmt.Errorf("failed to parse roles file %s: %s", path, err)
+	}
+
+	seen := map[string]bool{}
+	for _, role := range file.Roles {
+		if err := role.validate(); err != nil {
+			return nil, fmt.Errorf("invalid roles file %s: %s", path, err)
+		}
+
+		key := strings.ToLower(role.Name)
+		if seen[key] {
+			return nil, fmt.Errorf("invalid roles file %s: role %q is defined more than once", path, role.Name)
+		}
+		seen[key] = true
+	}
+
+	return file.Roles, nil
+}
+
+func NewApplyRolesCommand() *cobra.Command {
+	opts := &ApplyRolesOptions{}
+	cmd := &cobra.Command{
+		Use:   "apply -f roles.yaml",
+		Short: "create or update incident roles to match a file",
+		Long: `create or update incident roles to match a file, e.g.
+
+  roles:
+    - name: Incident Lead
+      shortform: lead
+      description: Coordinates the response
+      instructions: Keep the channel updated every 30 minutes
+    - name: Scribe
+      shortform: scribe
+      description: Keeps the timeline
+      instructions: Pin anything important
+
+Roles are matched by name, ignoring case. Roles missing from the file are left alone
+unless --prune is given, which deletes them, except for the built in roles.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, logger, cl, err := setup()
+			if err != nil {
+				fmt.Printf("failed to setup: %s", err)
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger, cl); err != nil {
+				logger.Log("msg", "failed to run", "error", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.file, "file", "f", "", "path to the roles file")
+	cmd.Flags().BoolVar(&opts.dryRun, "dry-run", false, "only print the changes that would be made")
+	cmd.Flags().BoolVar(&opts.prune, "prune", false, "delete custom roles that aren't in the file")
+
+	return cmd
+}
+
+type ApplyRolesOptions struct {
+	file   string
+	dryRun bool
+	prune  bool
+}
+
+func (o *ApplyRolesOptions) Run(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) error {
+	if o.file == "" {
+		return fmt.Errorf("--file is required")
+	}
+
+	desired, err := readRolesFile(o.file)
+	if err != nil {
+		return err
+	}
+
+	if !o.dryRun {
+		if err := RequireRoles(ctx, logger, cl, client.IdentityV1RolesManageSettings); err != nil {
+			return err
+		}
+	}
+
+	existing, err := ListAllIncidentRoles(ctx, logger, cl)
+	if err != nil {
+		return fmt.Errorf("failed to list incident roles: %s", err)
+	}
+
+	byName := lo.KeyBy(existing, func(role client.IncidentRoleV2) string { return strings.ToLower(role.Name) })
+
+	for _, role := range desired {
+		current, ok := byName[strings.ToLower(role.Name)]
+		delete(byName, strings.ToLower(role.Name))
+
+		if !ok {
+			logger.Log("msg", lo.Ternary(o.dryRun, "would create incident role", "creating incident role"), "name", role.Name)
+			if o.dryRun {
+				continue
+			}
+			if _, err := CreateIncidentRole(ctx, logger, cl, client.IncidentRolesV2CreateJSONRequestBody(role)); err != nil {
+				return fmt.Errorf("failed to create incident role %q: %s", role.Name, err)
+			}
+			continue
+		}
+
+		fields := role.differences(current)
+		if len(fields) == 0 {
+			logger.Log("msg", "incident role up to date", "name", role.Name)
+			continue
+		}
+
+		logger.Log("msg", lo.Ternary(o.dryRun, "would update incident role", "updating incident role"), "name", role.Name, "fields", strings.Join(fields, ","))
+		if o.dryRun {
+			continue
+		}
+		if _, err := UpdateIncidentRole(ctx, logger, cl, current.Id, client.IncidentRolesV2UpdateJSONRequestBody(role)); err != nil {
+			return fmt.Errorf("failed to update incident role %q: %s", role.Name, err)
+		}
+	}
+
+	if !o.prune {
+		return nil
+	}
+
+	for _, role := range existing {
+		if _, unlisted := byName[strings.ToLower(role.Name)]; !unlisted || role.RoleType != client.IncidentRoleV2RoleTypeCustom {
+			continue
+		}
+
+		logger.Log("msg", lo.Ternary(o.dryRun, "would delete incident role", "deleting incident role"), "name", role.Name)
+		if o.dryRun {
+			continue
+		}
+		if err := DeleteIncidentRole(ctx, logger, cl, role.Id); err != nil {
+			return fmt.Errorf("failed to delete incident role %q: %s", role.Name, err)
+		}
+	}
+
+	return nil
+}
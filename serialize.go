@@ -1,12 +1,24 @@
 package main
 
 import (
+	"encoding/csv"
 	"encoding/json"
+	"fmt"
+	"io"
 	"os"
+	"strings"
+	"text/tabwriter"
 
 	"github.com/pkg/errors"
 )
 
+const (
+	outputJSON  = "json"
+	outputJSONL = "jsonl"
+	outputTable = "table"
+	outputCSV   = "csv"
+)
+
 func serialize(data any) error {
 	enc := json.NewEncoder(os.Stdout)
 	enc.SetIndent("", "  ")
@@ -15,3 +27,110 @@ func serialize(data any) error {
 	}
 	return nil
 }
+
+// validateOutput checks an --output flag against the formats a command supports.
+func validateOutput(output string, supported ...string) error {
+	for _, format := range supported {
+		if output == format {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported output format %q, must be one of: %s", output, strings.Join(supported, ", "))
+}
+
+// recordWriter writes records to stdout one at a time as they are produced, so listings
+// never have to be held in memory. The json format still produces a single indented
+// array, identical to what serialize would print for the equivalent slice.
+type recordWriter struct {
+	out    io.Writer
+	format string
+	count  int
+}
+
+func newRecordWriter(format string) (*recordWriter, error) {
+	switch format {
+	case outputJSON, outputJSONL:
+	default:
+		return nil, fmt.Errorf("unsupported output format %q, must be one of: %s, %s", format, outputJSON, outputJSONL)
+	}
+
+	return &recordWriter{out: os.Stdout, format: format}, nil
+}
+
+func (w *recordWriter) Write(record any) error {
+	defer func() { w.count++ }()
+
+	if w.format == outputJSONL {
+		if err := json.NewEncoder(w.out).Encode(record); err != nil {
+			return errors.Wrap(err, "failed to marshal json")
+		}
+		return nil
+	}
+
+	data, err := json.MarshalIndent(record, "  ", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal json")
+	}
+
+	prefix := ",\n  "
+	if w.count == 0 {
+		prefix = "[\n  "
+	}
+
+	if _, err := fmt.Fprintf(w.out, "%s%s", prefix, data); err != nil {
+		return errors.Wrap(err, "failed to write output")
+	}
+	return nil
+}
+
+// Close terminates the json array. It is a no-op for jsonl.
+func (w *recordWriter) Close() error {
+	if w.format == outputJSONL {
+		return nil
+	}
+
+	closing := "\n]\n"
+	if w.count == 0 {
+		closing = "[]\n"
+	}
+
+	if _, err := io.WriteString(w.out, closing); err != nil {
+		return errors.Wrap(err, "failed to write output")
+	}
+	return nil
+}
+
+// writeTable prints rows to stdout as aligned columns under an upper case header. Only
+// the first line of multi-line cells, such as descriptions, is shown.
+func writeTable(headers []string, rows [][]string) error {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintln(tw, strings.ToUpper(strings.Join(headers, "\t")))
+	for _, row := range rows {
+		cells := make([]string, 0, len(row))
+		for _, cell := range row {
+			if line, _, multiline := strings.Cut(strings.TrimSpace(cell), "\n"); multiline {
+				cell = strings.TrimSpace(line) + " ..."
+			}
+			cells = append(cells, strings.ReplaceAll(cell, "\t", " "))
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+
+	if err := tw.Flush(); err != nil {
+		return errors.Wrap(err, "failed to write output")
+	}
+	return nil
+}
+
+// writeCSV prints rows to stdout as CSV under a header, for spreadsheets and scripts.
+func writeCSV(headers []string, rows [][]string) error {
+	w := csv.NewWriter(os.Stdout)
+	if err := w.Write(headers); err != nil {
+		return errors.Wrap(err, "failed to write output")
+	}
+	if err := w.WriteAll(rows); err != nil {
+		return errors.Wrap(err, "failed to write output")
+	}
+	return nil
+}
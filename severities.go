@@ -0,0 +1,298 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/alexeldeib/incli/client"
+	kitlog "github.com/go-kit/log"
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+)
+
+func NewSeveritiesCommand() *cobra.Command {
+	root := &cobra.Command{
+		Use:     "severities",
+		Aliases: []string{"severity", "sev"},
+		Short:   "manage incident severities",
+	}
+
+	root.AddCommand(NewGetSeveritiesCommand())
+	root.AddCommand(NewCreateSeverityCommand())
+	root.AddCommand(NewUpdateSeverityCommand())
+	root.AddCommand(NewDeleteSeverityCommand())
+
+	return root
+}
+
+func NewGetSeveritiesCommand() *cobra.Command {
+	opts := &GetSeveritiesOptions{}
+	cmd := &cobra.Command{
+		Use:   "get [NAME|ID]",
+		Short: "get one or all severities, most severe first",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) > 0 {
+				opts.severity = args[0]
+			}
+
+			ctx, logger, cl, err := setup()
+			if err != nil {
+				fmt.Printf("failed to setup: %s", err)
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger, cl); err != nil {
+				logger.Log("msg", "failed to run", "error", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.output, "output", "o", outputJSON, "output format, one of: json, jsonl, table")
+
+	return cmd
+}
+
+type GetSeveritiesOptions struct {
+	severity string
+	output   string
+}
+
+func (o *GetSeveritiesOptions) Run(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) error {
+	if err := validateOutput(o.output, outputJSON, outputJSONL, outputTable); err != nil {
+		return err
+	}
+
+	var severities []client.SeverityV2
+	if o.severity != "" {
+		severity, err := FindSeverity(ctx, logger, cl, o.severity)
+		if err != nil {
+			return fmt.Errorf("failed to find severity: %s", err)
+		}
+
+		if o.output == outputJSON {
+			if err := serialize(severity); err != nil {
+				return fmt.Errorf("failed to marshal json: %q", err)
+			}
+			return nil
+		}
+		severities = []client.SeverityV2{*severity}
+	} else {
+		res, err := ListAllSeverities(ctx, logger, cl)
+		if err != nil {
+			return fmt.Errorf("failed to list severities: %s", err)
+		}
+		severities = res
+	}
+
+	if o.output == outputTable {
+		rows := lo.Map(severities, func(v client.SeverityV2, _ int) []string {
+			return []string{v.Id, v.Name, strconv.FormatInt(v.Rank, 10), v.Description}
+		})
+		return writeTable([]string{"id", "name", "rank", "description"}, rows)
+	}
+
+	w, err := newRecordWriter(o.output)
+	if err != nil {
+		return err
+	}
+
+	for _, severity := range severities {
+		if err := w.Write(severity); err != nil {
+			return err
+		}
+	}
+
+	return w.Close()
+}
+
+func NewCreateSeverityCommand() *cobra.Command {
+	opts := &CreateSeverityOptions{}
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "create a severity",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			opts.rankSet = cmd.Flags().Changed("rank")
+
+			ctx, logger, cl, err := setup()
+			if err != nil {
+				fmt.Printf("failed to setup: %s", err)
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger, cl); err != nil {
+				logger.Log("msg", "failed to run", "error", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.name, "name", "", "name of the severity, e.g. Critical")
+	cmd.Flags().StringVar(&opts.description, "description", "", "when responders should pick this severity")
+	cmd.Flags().Int64Var(&opts.rank, "rank", 0, "rank of the severity, higher is more severe. Defaults to above the existing severities")
+
+	return cmd
+}
+
+type CreateSeverityOptions struct {
+	name        string
+	description string
+	rank        int64
+	rankSet     bool
+}
+
+func (o *CreateSeverityOptions) Run(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) error {
+	if o.name == "" {
+		return fmt.Errorf("--name is required")
+	}
+
+	body := client.SeveritiesV1CreateJSONRequestBody{
+		Name:        o.name,
+		Description: o.description,
+	}
+	if o.rankSet {
+		body.Rank = &o.rank
+	}
+
+	if err := RequireRoles(ctx, logger, cl, client.IdentityV1RolesManageSettings); err != nil {
+		return err
+	}
+
+	res, err := CreateSeverity(ctx, logger, cl, body)
+	if err != nil {
+		return fmt.Errorf("failed to create severity: %s", err)
+	}
+
+	if err := serialize(res); err != nil {
+		return fmt.Errorf("failed to marshal json: %q", err)
+	}
+
+	return nil
+}
+
+func NewUpdateSeverityCommand() *cobra.Command {
+	opts := &UpdateSeverityOptions{}
+	cmd := &cobra.Command{
+		Use:   "update NAME|ID",
+		Short: "rename, describe or re-rank a severity",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			opts.severity = args[0]
+			opts.descriptionSet = cmd.Flags().Changed("description")
+			opts.rankSet = cmd.Flags().Changed("rank")
+
+			ctx, logger, cl, err := setup()
+			if err != nil {
+				fmt.Printf("failed to setup: %s", err)
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger, cl); err != nil {
+				logger.Log("msg", "failed to run", "error", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.name, "name", "", "new name of the severity, unchanged if empty")
+	cmd.Flags().StringVar(&opts.description, "description", "", "new description of the severity, unchanged if not given")
+	cmd.Flags().Int64Var(&opts.rank, "rank", 0, "new rank of the severity, unchanged if not given")
+
+	return cmd
+}
+
+type UpdateSeverityOptions struct {
+	severity       string
+	name           string
+	description    string
+	descriptionSet bool
+	rank           int64
+	rankSet        bool
+}
+
+func (o *UpdateSeverityOptions) Run(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) error {
+	if err := RequireRoles(ctx, logger, cl, client.IdentityV1RolesManageSettings); err != nil {
+		return err
+	}
+
+	severity, err := FindSeverity(ctx, logger, cl, o.severity)
+	if err != nil {
+		return fmt.Errorf("failed to find severity: %s", err)
+	}
+
+	// the API replaces every field, so carry over whatever isn't being changed
+	body := client.SeveritiesV1UpdateJSONRequestBody{
+		Name:        lo.Ternary(o.name != "", o.name, severity.Name),
+		Description: lo.Ternary(o.descriptionSet, o.description, severity.Description),
+		Rank:        lo.ToPtr(lo.Ternary(o.rankSet, o.rank, severity.Rank)),
+	}
+
+	res, err := UpdateSeverity(ctx, logger, cl, severity.Id, body)
+	if err != nil {
+		return fmt.Errorf("failed to update severity: %s", err)
+	}
+
+	if err := serialize(res); err != nil {
+		return fmt.Errorf("failed to marshal json: %q", err)
+	}
+
+	return nil
+}
+
+func NewDeleteSeverityCommand() *cobra.Command {
+	opts := &DeleteSeverityOptions{}
+	cmd := &cobra.Command{
+		Use:   "delete NAME|ID",
+		Short: "delete a severity",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			opts.severity = args[0]
+
+			ctx, logger, cl, err := setup()
+			if err != nil {
+				fmt.Printf("failed to setup: %s", err)
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger, cl); err != nil {
+				logger.Log("msg", "failed to run", "error", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.yes, "yes", false, "confirm the deletion")
+
+	return cmd
+}
+
+type DeleteSeverityOptions struct {
+	severity string
+	yes      bool
+}
+
+func (o *DeleteSeverityOptions) Run(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) error {
+	if err := RequireRoles(ctx, logger, cl, client.IdentityV1RolesManageSettings); err != nil {
+		return err
+	}
+
+	severity, err := FindSeverity(ctx, logger, cl, o.severity)
+	if err != nil {
+		return fmt.Errorf("failed to find severity: %s", err)
+	}
+
+	if !o.yes {
+		return fmt.Errorf("deleting severity %q affects every incident using it, pass --yes to confirm", severity.Name)
+	}
+
+	if err := DeleteSeverity(ctx, logger, cl, severity.Id); err != nil {
+		return fmt.Errorf("failed to delete severity: %s", err)
+	}
+
+	logger.Log("msg", "deleted severity", "name", severity.Name, "id", severity.Id)
+	return nil
+}
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/alexeldeib/incli/client"
+	kitlog "github.com/go-kit/log"
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+)
+
+// incidentStatusCategories are the categories custom statuses can be created in. The
+// remaining categories, e.g. triage and declined, belong to built-in statuses.
+var incidentStatusCategories = []client.CreateRequestBody8Category{
+	client.CreateRequestBody8CategoryLive,
+	client.CreateRequestBody8CategoryLearning,
+	client.CreateRequestBody8CategoryClosed,
+}
+
+func NewStatusesCommand() *cobra.Command {
+	root := &cobra.Command{
+		Use:     "statuses",
+		Aliases: []string{"status"},
+		Short:   "manage incident statuses",
+	}
+
+	root.AddCommand(NewGetStatusesCommand())
+	root.AddCommand(NewCreateStatusCommand())
+	root.AddCommand(NewUpdateStatusCommand())
+	root.AddCommand(NewDeleteStatusCommand())
+
+	return root
+}
+
+func NewGetStatusesCommand() *cobra.Command {
+	opts := &GetStatusesOptions{}
+	cmd := &cobra.Command{
+		Use:   "get [NAME|ID]",
+		Short: "get one or all incident statuses, in lifecycle order",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) > 0 {
+				opts.status = args[0]
+			}
+
+			ctx, logger, cl, err := setup()
+			if err != nil {
+				fmt.Printf("failed to setup: %s", err)
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger, cl); err != nil {
+				logger.Log("msg", "failed to run", "error", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.output, "output", "o", outputJSON, "output format, one of: json, jsonl, table")
+
+	return cmd
+}
+
+type GetStatusesOptions struct {
+	status string
+	output string
+}
+
+func (o *GetStatusesOptions) Run(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) error {
+	if err := validateOutput(o.output, outputJSON, outputJSONL, outputTable); err != nil {
+		return err
+	}
+
+	var statuses []client.IncidentStatusV1
+	if o.status != "" {
+		status, err := FindIncidentStatus(ctx, logger, cl, o.status)
+		if err != nil {
+			return fmt.Errorf("failed to find incident status: %s", err)
+		}
+
+		if o.output == outputJSON {
+			if err := serialize(status); err != nil {
+				return fmt.Errorf("failed to marshal json: %q", err)
+			}
+			return nil
+		}
+		statuses = []client.IncidentStatusV1{*status}
+	} else {
+		res, err := ListAllIncidentStatuses(ctx, logger, cl)
+		if err != nil {
+			return fmt.Errorf("failed to list incident statuses: %s", err)
+		}
+		statuses = res
+	}
+
+	if o.output == outputTable {
+		rows := lo.Map(statuses, func(v client.IncidentStatusV1, _ int) []string {
+			return []string{v.Id, v.Name, string(v.Category), strconv.FormatInt(v.Rank, 10), v.Description}
+		})
+		return writeTable([]string{"id", "name", "category", "rank", "description"}, rows)
+	}
+
+	w, err := newRecordWriter(o.output)
+	if err != nil {
+		return err
+	}
+
+	for _, status := range statuses {
+		if err := w.Write(status); err != nil {
+			return err
+		}
+	}
+
+	return w.Close()
+}
+
+func NewCreateStatusCommand() *cobra.Command {
+	opts := &CreateStatusOptions{}
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "create an incident status",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, logger, cl, err := setup()
+			if err != nil {
+				fmt.Printf("failed to setup: %s", err)
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger, cl); err != nil {
+				logger.Log("msg", "failed to run", "error", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.name, "name", "", "name of the status, e.g. Monitoring")
+	cmd.Flags().StringVar(&opts.description, "description", "", "what the status means for responders")
+	cmd.Flags().StringVar(&opts.category, "category", "", "lifecycle category of the status, one of: live, learning, closed")
+
+	return cmd
+}
+
+type CreateStatusOptions struct {
+	name        string
+	description string
+	category    string
+}
+
+func (o *CreateStatusOptions) Run(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) error {
+	if o.name == "" {
+		return fmt.Errorf("--name is required")
+	}
+
+	category := client.CreateRequestBody8Category(o.category)
+	if !lo.Contains(incidentStatusCategories, category) {
+		return fmt.Errorf("--category must be one of %v: %q", incidentStatusCategories, o.category)
+	}
+
+	if err := RequireRoles(ctx, logger, cl, client.IdentityV1RolesManageSettings); err != nil {
+		return err
+	}
+
+	res, err := CreateIncidentStatus(ctx, logger, cl, client.IncidentStatusesV1CreateJSONRequestBody{
+		Name:        o.name,
+		Description: o.description,
+		Category:    category,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create incident status: %s", err)
+	}
+
+	if err := serialize(res); err != nil {
+		return fmt.Errorf("failed to marshal json: %q", err)
+	}
+
+	return nil
+}
+
+func NewUpdateStatusCommand() *cobra.Command {
+	opts := &UpdateStatusOptions{}
+	cmd := &cobra.Command{
+		Use:   "update NAME|ID",
+		Short: "rename an incident status or change its description",
+		Long: `rename an incident status or change its description.
+
+The API doesn't allow moving a status to another category, create a new status instead.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			opts.status = args[0]
+			opts.descriptionSet = cmd.Flags().Changed("description")
+
+			ctx, logger, cl, err := setup()
+			if err != nil {
+				fmt.Printf("failed to setup: %s", err)
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger, cl); err != nil {
+				logger.Log("msg", "failed to run", "error", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.name, "name", "", "new name of the status, unchanged if empty")
+	cmd.Flags().StringVar(&opts.description, "description", "", "new description of the status, unchanged if not given")
+
+	return cmd
+}
+
+type UpdateStatusOptions struct {
+	status         string
+	name           string
+	description    string
+	descriptionSet bool
+}
+
+func (o *UpdateStatusOptions) Run(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) error {
+	if err := RequireRoles(ctx, logger, cl, client.IdentityV1RolesManageSettings); err != nil {
+		return err
+	}
+
+	status, err := FindIncidentStatus(ctx, logger, cl, o.status)
+	if err != nil {
+		return fmt.Errorf("failed to find incident status: %s", err)
+	}
+
+	body := client.IncidentStatusesV1UpdateJSONRequestBody{
+		Name:        lo.Ternary(o.name != "", o.name, status.Name),
+		Description: lo.Ternary(o.descriptionSet, o.description, status.Description),
+	}
+
+	res, err := UpdateIncidentStatus(ctx, logger, cl, status.Id, body)
+	if err != nil {
+		return fmt.Errorf("failed to update incident status: %s", err)
+	}
+
+	if err := serialize(res); err != nil {
+		return fmt.Errorf("failed to marshal json: %q", err)
+	}
+
+	return nil
+}
+
+func NewDeleteStatusCommand() *cobra.Command {
+	opts := &DeleteStatusOptions{}
+	cmd := &cobra.Command{
+		Use:   "delete NAME|ID",
+		Short: "delete an incident status",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			opts.status = args[0]
+
+			ctx, logger, cl, err := setup()
+			if err != nil {
+				fmt.Printf("failed to setup: %s", err)
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger, cl); err != nil {
+				logger.Log("msg", "failed to run", "error", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.yes, "yes", false, "confirm the deletion")
+
+	return cmd
+}
+
+type DeleteStatusOptions struct {
+	status string
+	yes    bool
+}
+
+func (o *DeleteStatusOptions) Run(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) error {
+	if err := RequireRoles(ctx, logger, cl, client.IdentityV1RolesManageSettings); err != nil {
+		return err
+	}
+
+	status, err := FindIncidentStatus(ctx, logger, cl, o.status)
+	if err != nil {
+		return fmt.Errorf("failed to find incident status: %s", err)
+	}
+
+	if !o.yes {
+		return fmt.Errorf("deleting incident status %q affects every incident in it, pass --yes to confirm", status.Name)
+	}
+
+	if err := DeleteIncidentStatus(ctx, logger, cl, status.Id); err != nil {
+		return fmt.Errorf("failed to delete incident status: %s", err)
+	}
+
+	logger.Log("msg", "deleted incident status", "name", status.Name, "id", status.Id)
+	return nil
+}
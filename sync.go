@@ -0,0 +1,574 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/alexeldeib/incli/client"
+	kitlog "github.com/go-kit/log"
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+
+	_ "modernc.org/sqlite"
+)
+
+// sqliteTimeLayout is how times are stored, as UTC text that sorts chronologically and
+// that SQLite's date and time functions understand.
+const sqliteTimeLayout = "2006-01-02T15:04:05.000Z"
+
+// The resources sync keeps a high-water mark for.
+const (
+	syncResourceIncidents      = "incidents"
+	syncResourceFollowUps      = "follow_ups"
+	syncResourceCatalogEntries = "catalog_entries"
+)
+
+const syncSchema = `
+CREATE TABLE IF NOT EXISTS incidents (
+	id TEXT PRIMARY KEY,
+	reference TEXT NOT NULL,
+	name TEXT NOT NULL,
+	summary TEXT,
+	status_id TEXT NOT NULL,
+	status_name TEXT NOT NULL,
+	status_category TEXT NOT NULL,
+	severity_id TEXT,
+	severity_name TEXT,
+	severity_rank INTEGER,
+	incident_type_id TEXT,
+	incident_type_name TEXT,
+	mode TEXT NOT NULL,
+	visibility TEXT NOT NULL,
+	permalink TEXT,
+	creator TEXT,
+	workload_minutes_total REAL,
+	workload_minutes_working REAL,
+	workload_minutes_late REAL,
+	workload_minutes_sleeping REAL,
+	created_at TEXT NOT NULL,
+	updated_at TEXT NOT NULL,
+	raw TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS incident_timestamps (
+	incident_id TEXT NOT NULL,
+	timestamp_id TEXT NOT NULL,
+	name TEXT NOT NULL,
+	value TEXT NOT NULL,
+	PRIMARY KEY (incident_id, timestamp_id)
+);
+CREATE TABLE IF NOT EXISTS incident_role_assignments (
+	incident_id TEXT NOT NULL,
+	role_id TEXT NOT NULL,
+	role_name TEXT NOT NULL,
+	user_id TEXT NOT NULL,
+	user TEXT NOT NULL,
+	PRIMARY KEY (incident_id, role_id)
+);
+CREATE TABLE IF NOT EXISTS incident_custom_field_values (
+	incident_id TEXT NOT NULL,
+	custom_field_id TEXT NOT NULL,
+	custom_field_name TEXT NOT NULL,
+	value TEXT NOT NULL,
+	catalog_entry_id TEXT
+);
+CREATE INDEX IF NOT EXISTS incident_custom_field_values_incident_id ON incident_custom_field_values (incident_id);
+CREATE TABLE IF NOT EXISTS incident_updates (
+	id TEXT PRIMARY KEY,
+	incident_id TEXT NOT NULL,
+	new_status_id TEXT NOT NULL,
+	new_status_name TEXT NOT NULL,
+	new_severity_id TEXT,
+	new_severity_name TEXT,
+	message TEXT,
+	updater TEXT,
+	created_at TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS incident_updates_incident_id ON incident_updates (incident_id);
+CREATE TABLE IF NOT EXISTS follow_ups (
+	id TEXT PRIMARY KEY,
+	incident_id TEXT NOT NULL,
+	title TEXT NOT NULL,
+	description TEXT,
+	status TEXT NOT NULL,
+	priority TEXT,
+	assignee_id TEXT,
+	assignee TEXT,
+	issue_name TEXT,
+	issue_permalink TEXT,
+	completed_at TEXT,
+	created_at TEXT NOT NULL,
+	updated_at TEXT NOT NULL,
+	raw TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS follow_ups_incident_id ON follow_ups (incident_id);
+CREATE TABLE IF NOT EXISTS catalog_types (
+	id TEXT PRIMARY KEY,
+	name TEXT NOT NULL,
+	type_name TEXT NOT NULL,
+	description TEXT NOT NULL,
+	created_at TEXT NOT NULL,
+	updated_at TEXT NOT NULL,
+	raw TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS catalog_entries (
+	id TEXT PRIMARY KEY,
+	catalog_type_id TEXT NOT NULL,
+	name TEXT NOT NULL,
+	external_id TEXT,
+	aliases TEXT NOT NULL,
+	rank INTEGER NOT NULL,
+	archived_at TEXT,
+	created_at TEXT NOT NULL,
+	updated_at TEXT NOT NULL,
+	raw TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS catalog_entries_catalog_type_id ON catalog_entries (catalog_type_id);
+CREATE TABLE IF NOT EXISTS sync_state (
+	resource TEXT PRIMARY KEY,
+	high_water_mark TEXT NOT NULL,
+	synced_at TEXT NOT NULL
+);
+`
+
+func NewSyncCommand() *cobra.Command {
+	opts := &SyncOptions{}
+	cmd := &cobra.Command{
+		Use:   "sync",
+		Short: "copy incidents, follow-ups and the catalog into a local SQLite database",
+		Long: `copy incidents, follow-ups and the catalog into a local SQLite database.
+
+Creates the database if it doesn't exist, then saves incidents with their timestamps,
+role assignments, custom field values and updates, follow-ups, and catalog types and
+entries into tables of the same names, to query offline with inc query.
+
+Each run remembers the latest updated_at it has seen of incidents, follow-ups and
+catalog entries, and only saves what has changed since, so incidents' updates are only
+fetched again for incidents that changed. The API can't filter by updated_at, so every
+incident is still listed. --full saves everything again. Catalog entries and types that
+no longer exist are removed, and times are stored as UTC text such as
+2024-01-31T09:30:00.000Z. The raw columns hold the API's JSON, for json_extract.
+
+The whole run is one transaction, so an interrupted sync leaves the database as it was.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, logger, cl, err := setup()
+			if err != nil {
+				fmt.Printf("failed to setup: %s", err)
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger, cl); err != nil {
+				logger.Log("msg", "failed to run", "error", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.db, "db", "incidents.db", "path of the SQLite database")
+	cmd.Flags().BoolVar(&opts.full, "full", false, "save everything, not only what changed since the last sync")
+
+	return cmd
+}
+
+type SyncOptions struct {
+	db   string
+	full bool
+}
+
+// sqliteSync saves API resources into the database within one transaction.
+type sqliteSync struct {
+	tx *sql.Tx
+	// marks are the high-water marks of the last sync, next those this one will save.
+	marks, next map[string]time.Time
+
+	incidents, updates, followUps, catalogTypes, catalogEntries, removed int
+}
+
+func (o *SyncOptions) Run(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) error {
+	db, err := openSyncDB(ctx, o.db)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+	defer tx.Rollback()
+
+	s := &sqliteSync{tx: tx, marks: map[string]time.Time{}}
+	if !o.full {
+		if s.marks, err = loadHighWaterMarks(ctx, tx); err != nil {
+			return err
+		}
+	}
+	s.next = lo.Assign(s.marks)
+
+	if err := s.syncIncidents(ctx, logger, cl); err != nil {
+		return fmt.Errorf("failed to sync incidents: %s", err)
+	}
+	if err := s.syncFollowUps(ctx, logger, cl); err != nil {
+		return fmt.Errorf("failed to sync follow-ups: %s", err)
+	}
+	if err := s.syncCatalog(ctx, logger, cl); err != nil {
+		return fmt.Errorf("failed to sync catalog: %s", err)
+	}
+
+	now := time.Now()
+	for resource, mark := range s.next {
+		if _, err := tx.ExecContext(ctx, `INSERT OR REPLACE INTO sync_state (resource, high_water_mark, synced_at) VALUES (?, ?, ?)`,
+			resource, sqliteTime(mark), sqliteTime(now)); err != nil {
+			return errors.Wrap(err, "failed to save high-water mark")
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, "failed to commit")
+	}
+
+	logger.Log("msg", "synced", "db", o.db, "incidents", s.incidents, "incident_updates", s.updates, "follow_ups", s.followUps,
+		"catalog_types", s.catalogTypes, "catalog_entries", s.catalogEntries, "removed", s.removed)
+	return nil
+}
+
+// sqliteDSN returns a file: URI for the database at path, opened in mode, e.g. ro or rwc.
+// The path is escaped, as SQLite would otherwise read e.g. ? or # in it as part of the URI.
+func sqliteDSN(path, mode string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to find database")
+	}
+	// Windows paths such as C:/x need a leading slash, or C: is read as the host
+	uri := &url.URL{Scheme: "file", Path: filepath.ToSlash(abs), RawQuery: "mode=" + mode}
+	if !strings.HasPrefix(uri.Path, "/") {
+		uri.Path = "/" + uri.Path
+	}
+	return uri.String(), nil
+}
+
+// openSyncDB opens the database, creating it and its tables if needed.
+func openSyncDB(ctx context.Context, path string) (*sql.DB, error) {
+	dsn, err := sqliteDSN(path, "rwc")
+	if err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open database")
+	}
+	if _, err := db.ExecContext(ctx, syncSchema); err != nil {
+		db.Close()
+		return nil, errors.Wrap(err, "failed to create tables")
+	}
+	return db, nil
+}
+
+func loadHighWaterMarks(ctx context.Context, tx *sql.Tx) (map[string]time.Time, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT resource, high_water_mark FROM sync_state`)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read high-water marks")
+	}
+	defer rows.Close()
+
+	marks := map[string]time.Time{}
+	for rows.Next() {
+		var resource, mark string
+		if err := rows.Scan(&resource, &mark); err != nil {
+			return nil, errors.Wrap(err, "failed to read high-water marks")
+		}
+		if marks[resource], err = time.Parse(sqliteTimeLayout, mark); err != nil {
+			return nil, errors.Wrapf(err, "invalid high-water mark for %s", resource)
+		}
+	}
+	return marks, errors.Wrap(rows.Err(), "failed to read high-water marks")
+}
+
+// changed reports whether a resource updated at updatedAt is newer than the high-water
+// mark of the last sync, and moves the next mark up to it.
+func (s *sqliteSync) changed(resource string, updatedAt time.Time) bool {
+	if updatedAt.After(s.next[resource]) {
+		s.next[resource] = updatedAt
+	}
+	mark, ok := s.marks[resource]
+	return !ok || updatedAt.After(mark)
+}
+
+func (s *sqliteSync) syncIncidents(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) error {
+	var changed []client.IncidentV2
+	err := WalkIncidents(ctx, logger, cl, client.IncidentsV2ListParams{}, func(incident client.IncidentV2) error {
+		if s.changed(syncResourceIncidents, incident.UpdatedAt) {
+			changed = append(changed, incident)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, incident := range changed {
+		updates, err := ListAllIncidentUpdates(ctx, logger, cl, incident.Id)
+		if err != nil {
+			return err
+		}
+		if err := s.saveIncident(ctx, incident, updates); err != nil {
+			return errors.Wrapf(err, "saving %s", incident.Reference)
+		}
+		s.incidents++
+		s.updates += len(updates)
+	}
+	return nil
+}
+
+// saveIncident replaces an incident and everything stored alongside it.
+func (s *sqliteSync) saveIncident(ctx context.Context, incident client.IncidentV2, updates []client.IncidentUpdateV2) error {
+	raw, err := json.Marshal(incident)
+	if err != nil {
+		return err
+	}
+
+	var severityID, severityName, severityRank, typeID, typeName any
+	if incident.Severity != nil {
+		severityID, severityName, severityRank = incident.Severity.Id, incident.Severity.Name, incident.Severity.Rank
+	}
+	if incident.IncidentType != nil {
+		typeID, typeName = incident.IncidentType.Id, incident.IncidentType.Name
+	}
+
+	if _, err := s.tx.ExecContext(ctx, `INSERT OR REPLACE INTO incidents (id, reference, name, summary, status_id, status_name, status_category,
+severity_id, severity_name, severity_rank, incident_type_id, incident_type_name, mode, visibility, permalink, creator,
+workload_minutes_total, workload_minutes_working, workload_minutes_late, workload_minutes_sleeping, created_at, updated_at, raw)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		incident.Id, incident.Reference, incident.Name, incident.Summary,
+		incident.IncidentStatus.Id, incident.IncidentStatus.Name, string(incident.IncidentStatus.Category),
+		severityID, severityName, severityRank, typeID, typeName,
+		string(incident.Mode), string(incident.Visibility), incident.Permalink, sqliteNullable(actorLabel(incident.Creator)),
+		incident.WorkloadMinutesTotal, incident.WorkloadMinutesWorking, incident.WorkloadMinutesLate, incident.WorkloadMinutesSleeping,
+		sqliteTime(incident.CreatedAt), sqliteTime(incident.UpdatedAt), string(raw),
+	); err != nil {
+		return err
+	}
+
+	for _, table := range []string{"incident_timestamps", "incident_role_assignments", "incident_custom_field_values", "incident_updates"} {
+		if _, err := s.tx.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE incident_id = ?`, table), incident.Id); err != nil {
+			return err
+		}
+	}
+
+	for _, timestamp := range lo.FromPtr(incident.IncidentTimestampValues) {
+		if timestamp.Value == nil || timestamp.Value.Value == nil {
+			continue
+		}
+		if _, err := s.tx.ExecContext(ctx, `INSERT INTO incident_timestamps (incident_id, timestamp_id, name, value) VALUES (?, ?, ?, ?)`,
+			incident.Id, timestamp.IncidentTimestamp.Id, timestamp.IncidentTimestamp.Name, sqliteTime(*timestamp.Value.Value)); err != nil {
+			return err
+		}
+	}
+
+	for _, assignment := range incident.IncidentRoleAssignments {
+		if assignment.Assignee == nil {
+			continue
+		}
+		if _, err := s.tx.ExecContext(ctx, `INSERT INTO incident_role_assignments (incident_id, role_id, role_name, user_id, user) VALUES (?, ?, ?, ?, ?)`,
+			incident.Id, assignment.Role.Id, assignment.Role.Name, assignment.Assignee.Id, userLabel(assignment.Assignee)); err != nil {
+			return err
+		}
+	}
+
+	// one row per value, so multi-select fields can be grouped on
+	for _, entry := range incident.CustomFieldEntries {
+		for _, value := range entry.Values {
+			var catalogEntryID any
+			if value.ValueCatalogEntry != nil {
+				catalogEntryID = value.ValueCatalogEntry.Id
+			}
+			if _, err := s.tx.ExecContext(ctx, `INSERT INTO incident_custom_field_values (incident_id, custom_field_id, custom_field_name, value, catalog_entry_id) VALUES (?, ?, ?, ?, ?)`,
+				incident.Id, entry.CustomField.Id, entry.CustomField.Name, customFieldEntryValue(client.CustomFieldEntryV1{Values: []client.CustomFieldValueV1{value}}), catalogEntryID); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, update := range updates {
+		var severityID, severityName any
+		if update.NewSeverity != nil {
+			severityID, severityName = update.NewSeverity.Id, update.NewSeverity.Name
+		}
+		if _, err := s.tx.ExecContext(ctx, `INSERT OR REPLACE INTO incident_updates (id, incident_id, new_status_id, new_status_name, new_severity_id, new_severity_name, message, updater, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			update.Id, update.IncidentId, update.NewIncidentStatus.Id, update.NewIncidentStatus.Name, severityID, severityName,
+			update.Message, sqliteNullable(actorLabel(update.Updater)), sqliteTime(update.CreatedAt)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *sqliteSync) syncFollowUps(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) error {
+	followUps, err := ListAllFollowUps(ctx, logger, cl, client.FollowUpsV2ListParams{})
+	if err != nil {
+		return err
+	}
+
+	for _, followUp := range followUps {
+		if !s.changed(syncResourceFollowUps, followUp.UpdatedAt) {
+			continue
+		}
+
+		raw, err := json.Marshal(followUp)
+		if err != nil {
+			return err
+		}
+
+		var priority, assigneeID, assignee, issueName, issuePermalink any
+		if followUp.Priority != nil {
+			priority = followUp.Priority.Name
+		}
+		if followUp.Assignee != nil {
+			assigneeID, assignee = followUp.Assignee.Id, userLabel(followUp.Assignee)
+		}
+		if followUp.ExternalIssueReference != nil {
+			issueName, issuePermalink = followUp.ExternalIssueReference.IssueName, followUp.ExternalIssueReference.IssuePermalink
+		}
+
+		if _, err := s.tx.ExecContext(ctx, `INSERT OR REPLACE INTO follow_ups (id, incident_id, title, description, status, priority, assignee_id, assignee,
+issue_name, issue_permalink, completed_at, created_at, updated_at, raw)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			followUp.Id, followUp.IncidentId, followUp.Title, followUp.Description, string(followUp.Status), priority, assigneeID, assignee,
+			issueName, issuePermalink, sqliteTimePtr(followUp.CompletedAt), sqliteTime(followUp.CreatedAt), sqliteTime(followUp.UpdatedAt), string(raw),
+		); err != nil {
+			return errors.Wrapf(err, "saving follow-up %s", followUp.Id)
+		}
+		s.followUps++
+	}
+	return nil
+}
+
+// syncCatalog saves every catalog type, and the entries that changed. Catalog types and
+// entries can be deleted, so whatever the API no longer lists is removed.
+func (s *sqliteSync) syncCatalog(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) error {
+	// straight from the API, as the cached list may be out of date
+	res, err := cl.CatalogV2ListTypesWithResponse(ctx)
+	if err != nil {
+		return errors.Wrap(err, "listing catalog types")
+	}
+
+	existing, err := s.ids(ctx, `SELECT id FROM catalog_entries`)
+	if err != nil {
+		return err
+	}
+	seenEntries := map[string]bool{}
+
+	for _, catalogType := range res.JSON200.CatalogTypes {
+		raw, err := json.Marshal(catalogType)
+		if err != nil {
+			return err
+		}
+		if _, err := s.tx.ExecContext(ctx, `INSERT OR REPLACE INTO catalog_types (id, name, type_name, description, created_at, updated_at, raw) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			catalogType.Id, catalogType.Name, catalogType.TypeName, catalogType.Description, sqliteTime(catalogType.CreatedAt), sqliteTime(catalogType.UpdatedAt), string(raw)); err != nil {
+			return errors.Wrapf(err, "saving catalog type %s", catalogType.Name)
+		}
+		s.catalogTypes++
+
+		err = WalkCatalogEntriesByTypeID(ctx, logger, cl, catalogType.Id, defaultPageSize, func(entry client.CatalogEntryV2) error {
+			seenEntries[entry.Id] = true
+			if !s.changed(syncResourceCatalogEntries, entry.UpdatedAt) && existing[entry.Id] {
+				return nil
+			}
+
+			raw, err := json.Marshal(entry)
+			if err != nil {
+				return err
+			}
+			aliases, err := json.Marshal(lo.Ternary(entry.Aliases == nil, []string{}, entry.Aliases))
+			if err != nil {
+				return err
+			}
+
+			if _, err := s.tx.ExecContext(ctx, `INSERT OR REPLACE INTO catalog_entries (id, catalog_type_id, name, external_id, aliases, rank, archived_at, created_at, updated_at, raw)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+				entry.Id, entry.CatalogTypeId, entry.Name, entry.ExternalId, string(aliases), entry.Rank,
+				sqliteTimePtr(entry.ArchivedAt), sqliteTime(entry.CreatedAt), sqliteTime(entry.UpdatedAt), string(raw)); err != nil {
+				return errors.Wrapf(err, "saving catalog entry %s", entry.Name)
+			}
+			s.catalogEntries++
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for id := range existing {
+		if seenEntries[id] {
+			continue
+		}
+		if _, err := s.tx.ExecContext(ctx, `DELETE FROM catalog_entries WHERE id = ?`, id); err != nil {
+			return errors.Wrap(err, "removing catalog entry")
+		}
+		s.removed++
+	}
+
+	typeIDs := lo.Map(res.JSON200.CatalogTypes, func(v client.CatalogTypeV2, _ int) string { return v.Id })
+	existingTypes, err := s.ids(ctx, `SELECT id FROM catalog_types`)
+	if err != nil {
+		return err
+	}
+	for id := range existingTypes {
+		if lo.Contains(typeIDs, id) {
+			continue
+		}
+		if _, err := s.tx.ExecContext(ctx, `DELETE FROM catalog_types WHERE id = ?`, id); err != nil {
+			return errors.Wrap(err, "removing catalog type")
+		}
+		s.removed++
+	}
+
+	return nil
+}
+
+func (s *sqliteSync) ids(ctx context.Context, query string) (map[string]bool, error) {
+	rows, err := s.tx.QueryContext(ctx, query)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading ids")
+	}
+	defer rows.Close()
+
+	ids := map[string]bool{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, errors.Wrap(err, "reading ids")
+		}
+		ids[id] = true
+	}
+	return ids, errors.Wrap(rows.Err(), "reading ids")
+}
+
+func sqliteTime(t time.Time) string {
+	return t.UTC().Format(sqliteTimeLayout)
+}
+
+func sqliteTimePtr(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return sqliteTime(*t)
+}
+
+// sqliteNullable stores an empty string as NULL.
+func sqliteNullable(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
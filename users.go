@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/alexeldeib/incli/client"
+	kitlog "github.com/go-kit/log"
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+)
+
+var userRoles = []client.UserV1Role{
+	client.UserV1RoleOwner,
+	client.UserV1RoleAdministrator,
+	client.UserV1RoleResponder,
+	client.UserV1RoleViewer,
+	client.UserV1RoleUnset,
+}
+
+func NewUsersCommand() *cobra.Command {
+	root := &cobra.Command{
+		Use:     "users",
+		Aliases: []string{"user"},
+		Short:   "look up the users of your organisation",
+	}
+
+	root.AddCommand(NewGetUsersCommand())
+
+	return root
+}
+
+func NewGetUsersCommand() *cobra.Command {
+	opts := &GetUsersOptions{}
+	cmd := &cobra.Command{
+		Use:   "get [EMAIL|SLACK_ID|NAME|ID]",
+		Short: "get one or all users",
+		Long: `get one or all users.
+
+A user is found by ID, or otherwise by email address, Slack user ID or name ignoring
+case, the same as every --user flag. The user list is cached for an hour, pass
+--no-cache to see users who have only just joined.`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) > 0 {
+				opts.user = args[0]
+			}
+
+			ctx, logger, cl, err := setup()
+			if err != nil {
+				fmt.Printf("failed to setup: %s", err)
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger, cl); err != nil {
+				logger.Log("msg", "failed to run", "error", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.email, "email", "", "only the user with this email address, ignoring case")
+	cmd.Flags().StringVar(&opts.slackID, "slack-id", "", "only the user with this Slack user ID")
+	cmd.Flags().StringVar(&opts.role, "role", "", "only users with this role, one of: owner, administrator, responder, viewer, unset. The API stopped updating roles in March 2023")
+	cmd.Flags().StringVarP(&opts.output, "output", "o", outputJSON, "output format, one of: json, jsonl, table")
+
+	return cmd
+}
+
+type GetUsersOptions struct {
+	user    string
+	email   string
+	slackID string
+	role    string
+	output  string
+}
+
+func (o *GetUsersOptions) Run(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) error {
+	if err := validateOutput(o.output, outputJSON, outputJSONL, outputTable); err != nil {
+		return err
+	}
+
+	if o.role != "" && !lo.Contains(userRoles, client.UserV1Role(o.role)) {
+		return fmt.Errorf("--role must be one of %v: %q", userRoles, o.role)
+	}
+
+	if o.user != "" && (o.email != "" || o.slackID != "" || o.role != "") {
+		return fmt.Errorf("--email, --slack-id and --role can't be combined with a user argument")
+	}
+
+	var users []client.UserV1
+	if o.user != "" {
+		user, err := FindUser(ctx, logger, cl, o.user)
+		if err != nil {
+			return fmt.Errorf("failed to find user: %s", err)
+		}
+
+		if o.output == outputJSON {
+			if err := serialize(user); err != nil {
+				return fmt.Errorf("failed to marshal json: %q", err)
+			}
+			return nil
+		}
+		users = []client.UserV1{*user}
+	} else {
+		res, err := ListAllUsers(ctx, logger, cl)
+		if err != nil {
+			return fmt.Errorf("failed to list users: %s", err)
+		}
+
+		users = lo.Filter(res, func(user client.UserV1, _ int) bool {
+			if o.email != "" && !strings.EqualFold(lo.FromPtr(user.Email), o.email) {
+				return false
+			}
+			if o.slackID != "" && lo.FromPtr(user.SlackUserId) != o.slackID {
+				return false
+			}
+			return o.role == "" || string(user.Role) == o.role
+		})
+	}
+
+	if o.output == outputTable {
+		rows := lo.Map(users, func(v client.UserV1, _ int) []string {
+			return []string{v.Id, v.Name, lo.FromPtr(v.Email), lo.FromPtr(v.SlackUserId), string(v.Role)}
+		})
+		return writeTable([]string{"id", "name", "email", "slack id", "role"}, rows)
+	}
+
+	w, err := newRecordWriter(o.output)
+	if err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		if err := w.Write(user); err != nil {
+			return err
+		}
+	}
+
+	return w.Close()
+}
@@ -0,0 +1,338 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alexeldeib/incli/client"
+	kitlog "github.com/go-kit/log"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// maxWebhookBodySize bounds the deliveries the listener reads, far above anything
+// incident.io sends.
+const maxWebhookBodySize = 5 << 20
+
+// webhookPayloadTypes are the client types the payloads of known event types decode
+// into. Private incidents only ever carry their ID.
+var webhookPayloadTypes = map[string]func() any{
+	"public_incident.incident_created_v2":   func() any { return &client.IncidentV2{} },
+	"public_incident.incident_updated_v2":   func() any { return &client.IncidentV2{} },
+	"public_incident.action_created_v1":     func() any { return &client.ActionV1{} },
+	"public_incident.action_updated_v1":     func() any { return &client.ActionV1{} },
+	"public_incident.follow_up_created_v1":  func() any { return &client.FollowUpV2{} },
+	"public_incident.follow_up_updated_v1":  func() any { return &client.FollowUpV2{} },
+	"private_incident.incident_created_v2":  func() any { return &privateWebhookPayload{} },
+	"private_incident.incident_updated_v2":  func() any { return &privateWebhookPayload{} },
+	"private_incident.action_created_v1":    func() any { return &privateWebhookPayload{} },
+	"private_incident.action_updated_v1":    func() any { return &privateWebhookPayload{} },
+	"private_incident.follow_up_created_v1": func() any { return &privateWebhookPayload{} },
+	"private_incident.follow_up_updated_v1": func() any { return &privateWebhookPayload{} },
+}
+
+type privateWebhookPayload struct {
+	Id string `json:"id"`
+}
+
+// webhookDelivery is one verified webhook as printed by the listener.
+type webhookDelivery struct {
+	Id         string    `json:"id"`
+	ReceivedAt time.Time `json:"received_at"`
+	SentAt     time.Time `json:"sent_at"`
+	EventType  string    `json:"event_type"`
+	Payload    any       `json:"payload"`
+}
+
+func NewWebhooksCommand() *cobra.Command {
+	root := &cobra.Command{
+		Use:     "webhooks",
+		Aliases: []string{"webhook"},
+		Short:   "develop against incident.io webhooks",
+	}
+
+	root.AddCommand(NewListenWebhooksCommand())
+
+	return root
+}
+
+func NewListenWebhooksCommand() *cobra.Command {
+	opts := &ListenWebhooksOptions{}
+	cmd := &cobra.Command{
+		Use:   "listen",
+		Short: "receive webhook deliveries locally, verify them and print or forward them",
+		Long: `receive webhook deliveries locally, verify them and print or forward them.
+
+Every delivery must carry a valid signature made with the endpoint's signing secret,
+the whsec_... value shown next to the endpoint in incident.io, and must have been sent
+within --tolerance, or it is rejected with 401. Payloads of known event types are
+decoded into the same types the API returns, others are printed as they came.
+
+With --exec, each delivery's body is also piped to the command, run with sh -c, with
+INC_WEBHOOK_ID and INC_WEBHOOK_EVENT_TYPE set. The delivery is answered with 500 if the
+command fails, so a real sender would retry it. Deliveries are handled one at a time.
+
+Expose the port with a tunnel such as ngrok to receive real deliveries, or send signed
+test deliveries to it yourself. No API key is needed.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if opts.secret == "" {
+				opts.secret = os.Getenv("INC_WEBHOOK_SECRET")
+			}
+
+			ctx, logger := setupLocal()
+
+			if err := opts.Run(ctx, logger); err != nil {
+				logger.Log("msg", "failed to run", "error", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().IntVar(&opts.port, "port", 8080, "port to listen on")
+	cmd.Flags().StringVar(&opts.address, "address", "127.0.0.1", "address to listen on, 0.0.0.0 for every interface")
+	cmd.Flags().StringVar(&opts.secret, "secret", "", "the endpoint's signing secret, whsec_... defaults to $INC_WEBHOOK_SECRET")
+	cmd.Flags().DurationVar(&opts.tolerance, "tolerance", 5*time.Minute, "how far a delivery's timestamp may be from now")
+	cmd.Flags().StringVar(&opts.exec, "exec", "", "command to pipe each delivery's body to, e.g. \"jq .event_type\"")
+	cmd.Flags().StringVarP(&opts.output, "output", "o", outputText, "output format, one of: text, json, jsonl")
+
+	return cmd
+}
+
+type ListenWebhooksOptions struct {
+	port      int
+	address   string
+	secret    string
+	tolerance time.Duration
+	exec      string
+	output    string
+}
+
+func (o *ListenWebhooksOptions) Run(ctx context.Context, logger kitlog.Logger) error {
+	if err := validateOutput(o.output, outputText, outputJSON, outputJSONL); err != nil {
+		return err
+	}
+
+	if o.secret == "" {
+		return fmt.Errorf("--secret or INC_WEBHOOK_SECRET must be set")
+	}
+
+	key, err := decodeWebhookSecret(o.secret)
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(o.address, strconv.Itoa(o.port)))
+	if err != nil {
+		return errors.Wrap(err, "listening")
+	}
+
+	var mu sync.Mutex
+	server := &http.Server{
+		ReadHeaderTimeout: 10 * time.Second,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				http.Error(w, "webhooks must be POSTed", http.StatusMethodNotAllowed)
+				return
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBodySize))
+			if err != nil {
+				http.Error(w, "failed to read body", http.StatusBadRequest)
+				return
+			}
+
+			delivery, err := verifyWebhook(key, r.Header, body, time.Now(), o.tolerance)
+			if err != nil {
+				logger.Log("msg", "rejected webhook delivery", "remote", r.RemoteAddr, "error", err)
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+
+			if err := decodeWebhook(body, delivery); err != nil {
+				logger.Log("msg", "rejected webhook delivery", "id", delivery.Id, "error", err)
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			logger.Log("msg", "received webhook", "id", delivery.Id, "event_type", delivery.EventType)
+			if err := printWebhook(o.output, delivery); err != nil {
+				logger.Log("msg", "failed to print webhook", "id", delivery.Id, "error", err)
+			}
+
+			if o.exec != "" {
+				if err := forwardWebhook(r.Context(), o.exec, delivery, body); err != nil {
+					logger.Log("msg", "forwarded webhook failed", "id", delivery.Id, "error", err)
+					http.Error(w, "handler failed", http.StatusInternalServerError)
+					return
+				}
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+		}),
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	logger.Log("msg", "listening for webhooks", "address", listener.Addr().String())
+	if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+		return errors.Wrap(err, "serving")
+	}
+
+	return nil
+}
+
+// decodeWebhookSecret turns a whsec_ signing secret into the HMAC key it encodes.
+func decodeWebhookSecret(secret string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(secret, "whsec_"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid signing secret, must be whsec_ followed by base64: %s", err)
+	}
+	return key, nil
+}
+
+// verifyWebhook checks a delivery the way Svix, which sends incident.io's webhooks,
+// signs them: an HMAC-SHA256 of "id.timestamp.body", sent base64 encoded as one of the
+// space separated "v1,<signature>" values of the signature header.
+func verifyWebhook(key []byte, header http.Header, body []byte, now time.Time, tolerance time.Duration) (*webhookDelivery, error) {
+	webhookHeader := func(name string) string {
+		if v := header.Get("webhook-" + name); v != "" {
+			return v
+		}
+		return header.Get("svix-" + name)
+	}
+
+	id, timestamp, signatures := webhookHeader("id"), webhookHeader("timestamp"), webhookHeader("signature")
+	if id == "" || timestamp == "" || signatures == "" {
+		return nil, fmt.Errorf("missing webhook-id, webhook-timestamp or webhook-signature header")
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhook-timestamp: %q", timestamp)
+	}
+	sentAt := time.Unix(seconds, 0)
+	if skew := now.Sub(sentAt).Abs(); skew > tolerance {
+		return nil, fmt.Errorf("webhook-timestamp is %s away from now, more than the %s tolerance", skew.Round(time.Second), tolerance)
+	}
+
+	mac := hmac.New(sha256.New, key)
+	fmt.Fprintf(mac, "%s.%s.", id, timestamp)
+	mac.Write(body)
+	expected := mac.Sum(nil)
+
+	for _, signature := range strings.Fields(signatures) {
+		version, value, _ := strings.Cut(signature, ",")
+		if version != "v1" {
+			continue
+		}
+		decoded, err := base64.StdEncoding.DecodeString(value)
+		if err == nil && hmac.Equal(decoded, expected) {
+			return &webhookDelivery{Id: id, ReceivedAt: now, SentAt: sentAt}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no valid signature, check --secret is the endpoint's signing secret")
+}
+
+// decodeWebhook reads the event type of a delivery and decodes its payload, which is
+// keyed by the event type.
+func decodeWebhook(body []byte, delivery *webhookDelivery) error {
+	envelope := map[string]json.RawMessage{}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return errors.Wrap(err, "decoding body")
+	}
+
+	if err := json.Unmarshal(envelope["event_type"], &delivery.EventType); err != nil || delivery.EventType == "" {
+		return fmt.Errorf("body has no event_type")
+	}
+
+	payload, ok := envelope[delivery.EventType]
+	if !ok {
+		// an unexpected shape is still worth seeing
+		delivery.Payload = envelope
+		return nil
+	}
+
+	newPayload, ok := webhookPayloadTypes[delivery.EventType]
+	if !ok {
+		delivery.Payload = payload
+		return nil
+	}
+
+	delivery.Payload = newPayload()
+	if err := json.Unmarshal(payload, delivery.Payload); err != nil {
+		return errors.Wrapf(err, "decoding %s payload", delivery.EventType)
+	}
+	return nil
+}
+
+func printWebhook(output string, delivery *webhookDelivery) error {
+	switch output {
+	case outputJSON:
+		return serialize(delivery)
+	case outputJSONL:
+		w, err := newRecordWriter(outputJSONL)
+		if err != nil {
+			return err
+		}
+		return w.Write(delivery)
+	}
+
+	data, err := json.MarshalIndent(delivery.Payload, "  ", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal json")
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s  %s  %s", delivery.SentAt.In(time.Local).Format("2006-01-02 15:04:05 MST"), delivery.EventType, delivery.Id)
+	switch payload := delivery.Payload.(type) {
+	case *client.IncidentV2:
+		fmt.Fprintf(&b, "  %s %s: status %s", payload.Reference, payload.Name, payload.IncidentStatus.Name)
+	case *client.ActionV1:
+		fmt.Fprintf(&b, "  action %s: %s", payload.Id, payload.Status)
+	case *client.FollowUpV2:
+		fmt.Fprintf(&b, "  follow-up %s: %s", payload.Title, payload.Status)
+	case *privateWebhookPayload:
+		fmt.Fprintf(&b, "  private, %s", payload.Id)
+	}
+	fmt.Fprintf(&b, "\n  %s\n", data)
+
+	if _, err := io.WriteString(os.Stdout, b.String()); err != nil {
+		return errors.Wrap(err, "failed to write output")
+	}
+	return nil
+}
+
+// forwardWebhook pipes the body of a delivery to command, passing its output through.
+func forwardWebhook(ctx context.Context, command string, delivery *webhookDelivery, body []byte) error {
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Stdin = bytes.NewReader(body)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(), "INC_WEBHOOK_ID="+delivery.Id, "INC_WEBHOOK_EVENT_TYPE="+delivery.EventType)
+
+	return errors.Wrap(cmd.Run(), "running command")
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestDecodeWebhookSecret(t *testing.T) {
+	key := []byte("a signing key")
+	encoded := base64.StdEncoding.EncodeToString(key)
+
+	tests := []struct {
+		name    string
+		secret  string
+		want    string
+		wantErr bool
+	}{
+		{name: "whsec prefix", secret: "whsec_" + encoded, want: string(key)},
+		{name: "no prefix", secret: encoded, want: string(key)},
+		{name: "not base64", secret: "whsec_not base64!", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeWebhookSecret(tt.secret)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decodeWebhookSecret(%q) error = %v, wantErr %v", tt.secret, err, tt.wantErr)
+			}
+			if string(got) != tt.want {
+				t.Errorf("decodeWebhookSecret(%q) = %q, want %q", tt.secret, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyWebhook(t *testing.T) {
+	key := []byte("a signing key")
+	now := time.Unix(1700000000, 0)
+	body := []byte(`{"event_type":"public_incident.incident_created_v2"}`)
+	const id = "msg_2KWPBgLlAfxdpx2AI54pPJ85f4W"
+
+	sign := func(key []byte, id string, timestamp time.Time, body []byte) string {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(id + "." + strconv.FormatInt(timestamp.Unix(), 10) + "." + string(body)))
+		return "v1," + base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	}
+
+	headers := func(prefix, id string, timestamp time.Time, signature string) http.Header {
+		header := http.Header{}
+		header.Set(prefix+"-id", id)
+		header.Set(prefix+"-timestamp", strconv.FormatInt(timestamp.Unix(), 10))
+		header.Set(prefix+"-signature", signature)
+		return header
+	}
+
+	tests := []struct {
+		name    string
+		key     []byte
+		header  http.Header
+		body    []byte
+		wantErr bool
+	}{
+		{
+			name:   "valid",
+			header: headers("webhook", id, now, sign(key, id, now, body)),
+		},
+		{
+			name:   "svix headers",
+			header: headers("svix", id, now, sign(key, id, now, body)),
+		},
+		{
+			name:   "within tolerance",
+			header: headers("webhook", id, now.Add(-4*time.Minute), sign(key, id, now.Add(-4*time.Minute), body)),
+		},
+		{
+			name:   "one of several signatures",
+			header: headers("webhook", id, now, "v1,bm90IGl0 v2,aWdub3JlZA== "+sign(key, id, now, body)),
+		},
+		{
+			name:    "tampered body",
+			header:  headers("webhook", id, now, sign(key, id, now, body)),
+			body:    []byte(`{"event_type":"public_incident.incident_updated_v2"}`),
+			wantErr: true,
+		},
+		{
+			name:    "signed for another id",
+			header:  headers("webhook", id, now, sign(key, "msg_other", now, body)),
+			wantErr: true,
+		},
+		{
+			name:    "stale",
+			header:  headers("webhook", id, now.Add(-6*time.Minute), sign(key, id, now.Add(-6*time.Minute), body)),
+			wantErr: true,
+		},
+		{
+			name:    "from the future",
+			header:  headers("webhook", id, now.Add(6*time.Minute), sign(key, id, now.Add(6*time.Minute), body)),
+			wantErr: true,
+		},
+		{
+			name:    "wrong secret",
+			key:     []byte("another key"),
+			header:  headers("webhook", id, now, sign(key, id, now, body)),
+			wantErr: true,
+		},
+		{
+			name:    "signature without version",
+			header:  headers("webhook", id, now, sign(key, id, now, body)[len("v1,"):]),
+			wantErr: true,
+		},
+		{
+			name:    "missing headers",
+			header:  http.Header{},
+			wantErr: true,
+		},
+		{
+			name: "invalid timestamp",
+			header: http.Header{
+				"Webhook-Id":        {id},
+				"Webhook-Timestamp": {"yesterday"},
+				"Webhook-Signature": {sign(key, id, now, body)},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verifyKey, verifyBody := key, body
+			if tt.key != nil {
+				verifyKey = tt.key
+			}
+			if tt.body != nil {
+				verifyBody = tt.body
+			}
+
+			delivery, err := verifyWebhook(verifyKey, tt.header, verifyBody, now, 5*time.Minute)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("verifyWebhook() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && delivery.Id != id {
+				t.Errorf("verifyWebhook() delivery id = %q, want %q", delivery.Id, id)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/alexeldeib/incli/client"
+	kitlog "github.com/go-kit/log"
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+)
+
+func NewWhoamiCommand() *cobra.Command {
+	opts := &WhoamiOptions{}
+	cmd := &cobra.Command{
+		Use:   "whoami",
+		Short: "show the name of the API key in use and the roles granted to it",
+		Long: `show the name of the API key in use and the roles granted to it.
+
+Commands that change anything check the key has the roles they need before they start,
+e.g. incident_editor to edit incidents or manage_settings to change severities. The API
+doesn't say which organisation a key belongs to, so that isn't shown.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, logger, cl, err := setup()
+			if err != nil {
+				fmt.Printf("failed to setup: %s", err)
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger, cl); err != nil {
+				logger.Log("msg", "failed to run", "error", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.output, "output", "o", outputText, "output format, one of: text, json")
+
+	return cmd
+}
+
+type WhoamiOptions struct {
+	output string
+}
+
+func (o *WhoamiOptions) Run(ctx context.Context, logger kitlog.Logger, cl *client.ClientWithResponses) error {
+	if err := validateOutput(o.output, outputText, outputJSON); err != nil {
+		return err
+	}
+
+	identity, err := GetIdentity(ctx, logger, cl)
+	if err != nil {
+		return fmt.Errorf("failed to get identity: %s", err)
+	}
+
+	if o.output == outputJSON {
+		if err := serialize(identity); err != nil {
+			return fmt.Errorf("failed to marshal json: %q", err)
+		}
+		return nil
+	}
+
+	roles := lo.Map(identity.Roles, func(v client.IdentityV1Roles, _ int) string { return string(v) })
+	fmt.Printf("API key: %s\nRoles:   %s\n", identity.Name, strings.Join(roles, ", "))
+
+	return nil
+}